@@ -0,0 +1,99 @@
+package doubleclick
+
+const (
+	// Base64 alphabets.
+	b64Std     = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	b64WebSafe = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+// Decode table of both standard and web-safe base64 alphabets.
+var b64DecTable = func() (t [256]byte) {
+	for i := range t {
+		t[i] = 0xff
+	}
+	for i := 0; i < 64; i++ {
+		t[b64Std[i]] = byte(i)
+		t[b64WebSafe[i]] = byte(i)
+	}
+	return
+}()
+
+// Encode src to base64 using given alphabet and append result to dst.
+func b64Encode(dst, src []byte, alphabet string, pad bool) []byte {
+	n := len(src) / 3 * 3
+	for i := 0; i < n; i += 3 {
+		v := uint32(src[i])<<16 | uint32(src[i+1])<<8 | uint32(src[i+2])
+		dst = append(dst, alphabet[v>>18&0x3f], alphabet[v>>12&0x3f], alphabet[v>>6&0x3f], alphabet[v&0x3f])
+	}
+	switch len(src) - n {
+	case 1:
+		v := uint32(src[n]) << 16
+		dst = append(dst, alphabet[v>>18&0x3f], alphabet[v>>12&0x3f])
+		if pad {
+			dst = append(dst, '=', '=')
+		}
+	case 2:
+		v := uint32(src[n])<<16 | uint32(src[n+1])<<8
+		dst = append(dst, alphabet[v>>18&0x3f], alphabet[v>>12&0x3f], alphabet[v>>6&0x3f])
+		if pad {
+			dst = append(dst, '=')
+		}
+	}
+	return dst
+}
+
+// Length of decoded base64 string with or without paddings.
+func b64DecodedLen(src []byte) int {
+	n := len(src)
+	for n > 0 && src[n-1] == '=' {
+		n--
+	}
+	return n * 6 / 8
+}
+
+// Decode base64 string in any alphabet (standard or web-safe), with or without paddings, and append result to dst.
+//
+// Src may overlap the tail of dst since decoding never writes ahead of reading.
+func b64DecodeAny(dst, src []byte) ([]byte, error) {
+	// Trim paddings.
+	n := len(src)
+	for n > 0 && src[n-1] == '=' {
+		n--
+	}
+	if n%4 == 1 {
+		return dst, ErrBadBase64
+	}
+	src = src[:n]
+	var v uint32
+	for i := 0; i < n; i++ {
+		c := b64DecTable[src[i]]
+		if c == 0xff {
+			return dst, ErrBadBase64
+		}
+		v = v<<6 | uint32(c)
+		if i%4 == 3 {
+			dst = append(dst, byte(v>>16), byte(v>>8), byte(v))
+			v = 0
+		}
+	}
+	switch n % 4 {
+	case 2:
+		dst = append(dst, byte(v>>4))
+	case 3:
+		dst = append(dst, byte(v>>10), byte(v>>2))
+	}
+	return dst, nil
+}
+
+// Check if msg consists only of base64 symbols (both web-safe and standard alphabets).
+func isBase64(msg []byte) bool {
+	if len(msg) == 0 {
+		return false
+	}
+	for i := 0; i < len(msg); i++ {
+		if b64DecTable[msg[i]] == 0xff && msg[i] != '=' {
+			return false
+		}
+	}
+	return true
+}
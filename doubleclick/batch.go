@@ -0,0 +1,500 @@
+package doubleclick
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Minimal number of batch items processed by one goroutine in parallel methods.
+const batchChunkMin = 256
+
+// Pool of instances used by goroutines of parallel methods.
+var batchPool sync.Pool
+
+// Scratch space of batch methods.
+type batchScratch struct {
+	l hmacLanes
+	// Messages (or plains) of lanes and their indices in the batch.
+	msgs [lanes][]byte
+	idx  [lanes]int
+	// Storages of payloads, decoded messages and signatures of lanes.
+	payloads [lanes][bufPadLen]byte
+	raw      [lanes][bufPadLen + msgOverhead]byte
+	signs    [lanes][integritySignLen]byte
+}
+
+// Release references to the batch items.
+func (s *batchScratch) reset() {
+	for i := range s.msgs {
+		s.msgs[i] = nil
+	}
+}
+
+// Get scratch space of batch methods.
+func (d *DoubleClick) scratch() *batchScratch {
+	if d.bscr == nil {
+		d.bscr = &batchScratch{}
+	}
+	return d.bscr
+}
+
+// Check if batches should use multi-buffer HMAC.
+//
+// Only AVX2 code pays off: pure Go lanes are slower than scalar HMAC and serve as a reference of assembly code.
+func useLanes() bool {
+	return useAVX2
+}
+
+// EncryptBatch encrypts plains using corresponding init vectors and stores messages to dst and per-item errors to errs.
+//
+// Items of dst are reused (message is appended to dst[i][:0]). dst, errs and initVecs must be at least of length of
+// plains. Returns number of failed items.
+func (d *DoubleClick) EncryptBatch(dst [][]byte, errs []error, initVecs, plains [][]byte) (int, error) {
+	n := len(plains)
+	if len(dst) < n || len(errs) < n || len(initVecs) < n {
+		return 0, ErrBadBatchLen
+	}
+	ti, ok := lookupType(d.typ)
+	if !ok {
+		return 0, ErrUnkType
+	}
+	if useLanes() && ti.payloadLen > 0 && ti.payloadLen <= bufPadLen {
+		return d.encryptLanes(dst, errs, initVecs, ti.payloadLen, n, func(dst []byte, i int) ([]byte, error) {
+			if ti.encFn != nil {
+				return ti.encFn(dst, plains[i]), nil
+			}
+			return append(dst, plains[i]...), nil
+		}), nil
+	}
+	var failed int
+	for i := 0; i < n; i++ {
+		if dst[i], errs[i] = d.EncryptFn(dst[i][:0], initVecs[i], plains[i], nil); errs[i] != nil {
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// EncryptPriceBatch encrypts prices using corresponding init vectors.
+//
+// See EncryptBatch for details.
+func (d *DoubleClick) EncryptPriceBatch(dst [][]byte, errs []error, prices []float64, initVecs [][]byte, micros int) (int, error) {
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	n := len(prices)
+	if len(dst) < n || len(errs) < n || len(initVecs) < n {
+		return 0, ErrBadBatchLen
+	}
+	return d.encryptPriceBatch(dst, errs, initVecs, n, func(i int) (uint64, error) {
+		return priceMicros(prices[i], micros, d.round)
+	}), nil
+}
+
+// EncryptPriceMicrosBatch encrypts prices already multiplied to micros using corresponding init vectors.
+//
+// See EncryptBatch for details.
+func (d *DoubleClick) EncryptPriceMicrosBatch(dst [][]byte, errs []error, prices []uint64, initVecs [][]byte) (int, error) {
+	n := len(prices)
+	if len(dst) < n || len(errs) < n || len(initVecs) < n {
+		return 0, ErrBadBatchLen
+	}
+	return d.encryptPriceBatch(dst, errs, initVecs, n, func(i int) (uint64, error) {
+		return prices[i], nil
+	}), nil
+}
+
+// DecryptBatch decrypts ciphers to corresponding items of dst and stores per-item errors to errs.
+//
+// Items of dst are reused (decrypted payload is appended to dst[i][:0]). dst and errs must be at least of length of
+// ciphers. Returns number of failed items.
+func (d *DoubleClick) DecryptBatch(dst [][]byte, errs []error, ciphers [][]byte) (int, error) {
+	if len(dst) < len(ciphers) || len(errs) < len(ciphers) {
+		return 0, ErrBadBatchLen
+	}
+	return d.decryptBatch(dst, errs, ciphers, 0, len(ciphers)), nil
+}
+
+// DecryptBatchWebSafe decrypts web-safe base64 encoded ciphers.
+//
+// See DecryptBatch for details.
+func (d *DoubleClick) DecryptBatchWebSafe(dst [][]byte, errs []error, ciphers [][]byte) (int, error) {
+	enc := d.enc
+	d.enc = EncodingWebSafe
+	failed, err := d.DecryptBatch(dst, errs, ciphers)
+	d.enc = enc
+	return failed, err
+}
+
+// DecryptBatchParallel decrypts large batches of ciphers using up to workers goroutines.
+//
+// Order of output items matches order of ciphers. Non-positive workers means GOMAXPROCS.
+// See DecryptBatch for details.
+func (d *DoubleClick) DecryptBatchParallel(dst [][]byte, errs []error, ciphers [][]byte, workers int) (int, error) {
+	if len(dst) < len(ciphers) || len(errs) < len(ciphers) {
+		return 0, ErrBadBatchLen
+	}
+	return d.parallel(len(ciphers), workers, func(x *DoubleClick, lo, hi int) int {
+		return x.decryptBatch(dst, errs, ciphers, lo, hi)
+	}), nil
+}
+
+// DecryptPriceBatch decrypts prices of ciphers to corresponding items of dst and stores per-item errors to errs.
+//
+// dst and errs must be at least of length of ciphers. Returns number of failed items.
+func (d *DoubleClick) DecryptPriceBatch(dst []float64, errs []error, ciphers [][]byte, micros int) (int, error) {
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	if len(dst) < len(ciphers) || len(errs) < len(ciphers) {
+		return 0, ErrBadBatchLen
+	}
+	return d.decryptPriceBatch(dst, errs, ciphers, micros, 0, len(ciphers)), nil
+}
+
+// DecryptPriceBatchWebSafe decrypts prices of web-safe base64 encoded ciphers.
+//
+// See DecryptPriceBatch for details.
+func (d *DoubleClick) DecryptPriceBatchWebSafe(dst []float64, errs []error, ciphers [][]byte, micros int) (int, error) {
+	enc := d.enc
+	d.enc = EncodingWebSafe
+	failed, err := d.DecryptPriceBatch(dst, errs, ciphers, micros)
+	d.enc = enc
+	return failed, err
+}
+
+// DecryptPriceBatchParallel decrypts prices of large batches of ciphers using up to workers goroutines.
+//
+// Order of output items matches order of ciphers. Non-positive workers means GOMAXPROCS.
+// See DecryptPriceBatch for details.
+func (d *DoubleClick) DecryptPriceBatchParallel(dst []float64, errs []error, ciphers [][]byte, micros, workers int) (int, error) {
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	if len(dst) < len(ciphers) || len(errs) < len(ciphers) {
+		return 0, ErrBadBatchLen
+	}
+	return d.parallel(len(ciphers), workers, func(x *DoubleClick, lo, hi int) int {
+		return x.decryptPriceBatch(dst, errs, ciphers, micros, lo, hi)
+	}), nil
+}
+
+// DecryptPriceMicrosBatch decrypts prices of ciphers without division to micros.
+//
+// See DecryptPriceBatch for details.
+func (d *DoubleClick) DecryptPriceMicrosBatch(dst []uint64, errs []error, ciphers [][]byte) (int, error) {
+	if len(dst) < len(ciphers) || len(errs) < len(ciphers) {
+		return 0, ErrBadBatchLen
+	}
+	var failed int
+	if useLanes() {
+		d.decryptLanes(ciphers, payloadLenPrice, 0, len(ciphers), func(i int, payload []byte, err error) {
+			if dst[i], errs[i] = 0, err; err != nil {
+				failed++
+				return
+			}
+			dst[i] = binary.BigEndian.Uint64(payload)
+		})
+		return failed, nil
+	}
+	for i := 0; i < len(ciphers); i++ {
+		if dst[i], errs[i] = d.decryptPriceOne(ciphers[i]); errs[i] != nil {
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// Encrypt prices of the batch; price of item i is provided by fn.
+func (d *DoubleClick) encryptPriceBatch(dst [][]byte, errs []error, initVecs [][]byte, n int, fn func(i int) (uint64, error)) (failed int) {
+	if useLanes() {
+		return d.encryptLanes(dst, errs, initVecs, payloadLenPrice, n, func(dst []byte, i int) ([]byte, error) {
+			price, err := fn(i)
+			if err != nil {
+				return dst, err
+			}
+			var b [payloadLenPrice]byte
+			binary.BigEndian.PutUint64(b[:], price)
+			return append(dst, b[:]...), nil
+		})
+	}
+	for i := 0; i < n; i++ {
+		price, err := fn(i)
+		if err == nil {
+			var b [payloadLenPrice]byte
+			binary.BigEndian.PutUint64(b[:], price)
+			d.pbuf = append(d.pbuf[:0], b[:]...)
+			dst[i], err = d.encrypt(dst[i][:0], initVecs[i], d.pbuf, payloadLenPrice, nil)
+		}
+		if errs[i] = err; err != nil {
+			dst[i] = dst[i][:0]
+			failed++
+		}
+	}
+	return
+}
+
+// Encrypt first n items of the batch of one-block payloads using multi-buffer HMAC.
+//
+// Plain of item i is appended to dst by plainFn.
+func (d *DoubleClick) encryptLanes(dst [][]byte, errs []error, initVecs [][]byte, plainLen, n int,
+	plainFn func(dst []byte, i int) ([]byte, error)) (failed int) {
+	s := d.scratch()
+	defer s.reset()
+	for i := 0; i < n; {
+		// Collect plains of the next chunk and put them to lanes.
+		c := 0
+		for ; i < n && c < lanes; i++ {
+			initVec := initVecs[i]
+			err := ErrBadInitvLen
+			if len(initVec) == initVectorLen {
+				if s.msgs[c], err = plainFn(s.payloads[c][:0], i); err == nil && len(s.msgs[c]) != plainLen {
+					err = ErrBadPlainLen
+				}
+			}
+			if err != nil {
+				dst[i], errs[i] = dst[i][:0], err
+				failed++
+				continue
+			}
+			s.idx[c] = i
+			s.l.set(c, s.msgs[c], initVec)
+			c++
+		}
+		if c == 0 {
+			continue
+		}
+
+		// Compute signatures.
+		s.l.sum(&d.hmacI, c)
+		for j := 0; j < c; j++ {
+			copy(s.signs[j][:], s.l.out[j][:])
+			s.l.set(j, initVecs[s.idx[j]], nil)
+		}
+
+		// Compute pads and fill destination arrays.
+		s.l.sum(&d.hmacE, c)
+		for j := 0; j < c; j++ {
+			k := s.idx[j]
+			msg := append(dst[k][:0], initVecs[k]...)
+			for p, b := range s.msgs[j] {
+				msg = append(msg, b^s.l.out[j][p])
+			}
+			msg = append(msg, s.signs[j][:]...)
+
+			// Apply wire encoding.
+			if d.enc != EncodingRaw {
+				d.wbuf = append(d.wbuf[:0], msg...)
+				msg = d.enc.Encode(msg[:0], d.wbuf)
+			}
+			dst[k], errs[k] = msg, nil
+		}
+	}
+	return
+}
+
+// Decrypt items [lo, hi) of the batch of one-block payloads using multi-buffer HMAC.
+//
+// Calls fn for every item with decrypted payload or error. Payload is valid only during the call.
+func (d *DoubleClick) decryptLanes(ciphers [][]byte, payloadLen, lo, hi int, fn func(i int, payload []byte, err error)) {
+	s := d.scratch()
+	defer s.reset()
+	msgLen := payloadLen + msgOverhead
+	for i := lo; i < hi; {
+		// Collect messages of the next chunk and put init vectors to lanes.
+		c := 0
+		for ; i < hi && c < lanes; i++ {
+			msg := ciphers[i]
+			// Apply wire encoding.
+			if d.enc != EncodingRaw {
+				var err error
+				if d.wbuf, err = d.enc.Decode(d.wbuf[:0], msg); err != nil {
+					fn(i, nil, err)
+					continue
+				}
+				msg = d.wbuf
+			}
+			if len(msg) != msgLen {
+				fn(i, nil, ErrBadMsgLen)
+				continue
+			}
+			if d.enc != EncodingRaw {
+				// Internal buffer will be overwritten by the next message.
+				msg = s.raw[c][:copy(s.raw[c][:], msg)]
+			}
+			s.msgs[c], s.idx[c] = msg, i
+			s.l.set(c, msg[initVectorOffset:initVectorLen], nil)
+			c++
+		}
+		if c == 0 {
+			continue
+		}
+
+		// Compute pads and apply xor to reverse encryption.
+		s.l.sum(&d.hmacE, c)
+		for j := 0; j < c; j++ {
+			msg, payload := s.msgs[j], s.payloads[j][:payloadLen]
+			for p := range payload {
+				payload[p] = msg[cipherOffset+p] ^ s.l.out[j][p]
+			}
+			s.l.set(j, payload, msg[initVectorOffset:initVectorLen])
+		}
+
+		// Compute and check signatures.
+		s.l.sum(&d.hmacI, c)
+		for j := 0; j < c; j++ {
+			msg := s.msgs[j]
+			initVector := msg[initVectorOffset:initVectorLen]
+			integritySign := msg[cipherOffset+payloadLen:]
+			if !hmac.Equal(s.l.out[j][:integritySignLen], integritySign) {
+				fn(s.idx[j], nil, ErrSignCheckFail)
+				continue
+			}
+			// Check decryption policy.
+			if d.policy != nil {
+				if err := d.policy.check(initVector, integritySign); err != nil {
+					fn(s.idx[j], nil, err)
+					continue
+				}
+			}
+			fn(s.idx[j], s.payloads[j][:payloadLen], nil)
+		}
+	}
+}
+
+// Decrypt items [lo, hi) of the batch.
+func (d *DoubleClick) decryptBatch(dst [][]byte, errs []error, ciphers [][]byte, lo, hi int) (failed int) {
+	if ti, ok := lookupType(d.typ); ok && useLanes() && ti.payloadLen > 0 && ti.payloadLen <= bufPadLen {
+		d.decryptLanes(ciphers, ti.payloadLen, lo, hi, func(i int, payload []byte, err error) {
+			if dst[i], errs[i] = dst[i][:0], err; err != nil {
+				failed++
+				return
+			}
+			if ti.decFn != nil {
+				dst[i] = ti.decFn(dst[i], payload)
+			} else {
+				dst[i] = append(dst[i], payload...)
+			}
+		})
+		return
+	}
+	for i := lo; i < hi; i++ {
+		if dst[i], errs[i] = d.DecryptFn(dst[i][:0], ciphers[i], nil); errs[i] != nil {
+			failed++
+		}
+	}
+	return
+}
+
+// Decrypt prices of items [lo, hi) of the batch.
+func (d *DoubleClick) decryptPriceBatch(dst []float64, errs []error, ciphers [][]byte, micros, lo, hi int) (failed int) {
+	fmicros := float64(micros)
+	if useLanes() {
+		d.decryptLanes(ciphers, payloadLenPrice, lo, hi, func(i int, payload []byte, err error) {
+			if dst[i], errs[i] = 0, err; err != nil {
+				failed++
+				return
+			}
+			dst[i] = float64(binary.BigEndian.Uint64(payload)) / fmicros
+		})
+		return
+	}
+	for i := lo; i < hi; i++ {
+		price, err := d.decryptPriceOne(ciphers[i])
+		if dst[i], errs[i] = float64(price)/fmicros, err; err != nil {
+			dst[i] = 0
+			failed++
+		}
+	}
+	return
+}
+
+// Decrypt price of one batch item.
+//
+// Unlike DecryptPriceMicros skips type lookup since price message length is fixed.
+func (d *DoubleClick) decryptPriceOne(cipher []byte) (uint64, error) {
+	// Increase buffer with +1 payload length and use extra space as a destination array.
+	bufLen := bufPadLen + payloadLenPrice + bufSignLen
+	doubleBufLen := bufLen + payloadLenPrice
+	if len(d.buf) < doubleBufLen {
+		d.buf = append(d.buf, make([]byte, doubleBufLen-len(d.buf))...)
+	}
+
+	// Apply wire encoding.
+	if d.enc != EncodingRaw {
+		var err error
+		if d.wbuf, err = d.enc.Decode(d.wbuf[:0], cipher); err != nil {
+			return 0, err
+		}
+		cipher = d.wbuf
+	}
+	if len(cipher) != msgLenPrice {
+		return 0, ErrBadMsgLen
+	}
+
+	decrypted, err := d.decrypt(d.buf[bufLen:bufLen], cipher, payloadLenPrice, nil)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(decrypted), nil
+}
+
+// Split n batch items to chunks and process them in parallel by clones of d.
+//
+// Returns total number of failed items.
+func (d *DoubleClick) parallel(n, workers int, fn func(x *DoubleClick, lo, hi int) int) int {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunks := (n + batchChunkMin - 1) / batchChunkMin; workers > chunks {
+		workers = chunks
+	}
+	if workers <= 1 {
+		return fn(d, 0, n)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		failed int64
+	)
+	step := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += step {
+		hi := lo + step
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			x := d.clone()
+			atomic.AddInt64(&failed, int64(fn(x, lo, hi)))
+			x.Reset()
+			x.SetPolicy(nil)
+			batchPool.Put(x)
+		}(lo, hi)
+	}
+	wg.Wait()
+	return int(failed)
+}
+
+// Make a copy of d with the same type, keys and settings.
+//
+// HMAC key schedules are copied as is, so clone doesn't pay for keys setup.
+func (d *DoubleClick) clone() *DoubleClick {
+	x, _ := batchPool.Get().(*DoubleClick)
+	if x == nil {
+		x = &DoubleClick{}
+	}
+	x.typ = d.typ
+	x.ekey = append(x.ekey[:0], d.ekey...)
+	x.ikey = append(x.ikey[:0], d.ikey...)
+	// Clones may run concurrently with each other.
+	x.hmacE, x.hmacI, x.keyed = d.hmacE.shared(), d.hmacI.shared(), d.keyed
+	x.policy = d.policy
+	x.round = d.round
+	x.enc = d.enc
+	return x
+}
@@ -0,0 +1,256 @@
+package doubleclick
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// Make n encrypted prices with distinct init vectors; each third message is corrupted.
+func batchPrices(tb testing.TB, n int) ([][]byte, []uint64) {
+	d := New(TypePrice, encryptionKey, integrityKey)
+	ciphers := make([][]byte, n)
+	prices := make([]uint64, n)
+	iv := append([]byte(nil), initVector...)
+	for i := 0; i < n; i++ {
+		binary.BigEndian.PutUint32(iv[12:], uint32(i))
+		prices[i] = uint64(i) * 1000
+		cipher, err := d.EncryptPriceMicros(prices[i], nil, iv)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if i%3 == 2 {
+			cipher[len(cipher)-1] ^= 0xff
+		}
+		ciphers[i] = cipher
+	}
+	return ciphers, prices
+}
+
+// Run fn with all available implementations of batch HMAC code.
+func eachBatchImpl(t *testing.T, fn func(t *testing.T)) {
+	t.Run("native", fn)
+	avx2, shani := useAVX2, useSHANI
+	defer func() { useAVX2, useSHANI = avx2, shani }()
+	if avx2 {
+		// Scalar code.
+		useAVX2 = false
+		t.Run("scalar", fn)
+	}
+	if shani {
+		// Scalar code using stdlib HMAC.
+		useAVX2, useSHANI = false, false
+		t.Run("stdlib", fn)
+	}
+}
+
+func TestBatchEncrypt(t *testing.T) {
+	ciphers, prices := batchPrices(t, 50)
+	initVecs := make([][]byte, len(ciphers))
+	fprices := make([]float64, len(prices))
+	for i := range ciphers {
+		initVecs[i] = ciphers[i][:initVectorLen]
+		fprices[i] = float64(prices[i]) / float64(micros)
+	}
+	initVecs[7] = initVecs[7][:10]
+	fprices[9] = -1
+	check := func(t *testing.T, dst [][]byte, errs []error, failed int) {
+		if failed != 2 || errs[7] != ErrBadInitvLen || errs[9] != ErrBadPrice || len(dst[7]) != 0 {
+			t.Error("unexpected errors", failed, errs[7], errs[9])
+		}
+		d := New(TypePrice, encryptionKey, integrityKey)
+		for i := range dst {
+			if i == 7 || i == 9 {
+				continue
+			}
+			expect, _ := d.EncryptPriceMicros(prices[i], nil, initVecs[i])
+			if errs[i] != nil || !bytes.Equal(dst[i], expect) {
+				t.Error("encrypt batch mismatch at", i)
+			}
+		}
+	}
+	eachBatchImpl(t, func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		dst := make([][]byte, len(prices))
+		errs := make([]error, len(prices))
+		failed, err := d.EncryptPriceBatch(dst, errs, fprices, initVecs, micros)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, dst, errs, failed)
+
+		plains := make([][]byte, len(prices))
+		for i := range plains {
+			plains[i] = make([]byte, payloadLenPrice)
+			binary.BigEndian.PutUint64(plains[i], prices[i])
+		}
+		plains[9] = plains[9][:3]
+		if failed, err = d.EncryptBatch(dst, errs, initVecs, plains); err != nil || errs[9] != ErrBadPlainLen {
+			t.Fatal(err, errs[9])
+		}
+		errs[9] = ErrBadPrice
+		check(t, dst, errs, failed)
+
+		d.SetEncoding(EncodingHex)
+		if _, err = d.EncryptPriceMicrosBatch(dst[:1], errs, prices[:1], initVecs); err != nil || errs[0] != nil {
+			t.Fatal(err, errs[0])
+		}
+		if string(dst[0]) != hex.EncodeToString(ciphers[0]) {
+			t.Error("encrypt batch encoding failed")
+		}
+	})
+}
+
+func TestBatch(t *testing.T) {
+	eachBatchImpl(t, testBatch)
+}
+
+func testBatch(t *testing.T) {
+	t.Run("decrypt", func(t *testing.T) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		ciphers := [][]byte{encryptedAdID, encryptedAdID[:10], encryptedAdID}
+		dst := make([][]byte, len(ciphers))
+		errs := make([]error, len(ciphers))
+		failed, err := d.DecryptBatch(dst, errs, ciphers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed != 1 || errs[0] != nil || errs[1] != ErrBadMsgLen || errs[2] != nil {
+			t.Error("unexpected errors", failed, errs)
+		}
+		if !bytes.Equal(dst[0], decryptedAdID) || !bytes.Equal(dst[2], decryptedAdID) {
+			t.Error("decrypt batch failed")
+		}
+	})
+	t.Run("decrypt web-safe", func(t *testing.T) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		ciphers := [][]byte{[]byte(base64.RawURLEncoding.EncodeToString(encryptedAdID))}
+		dst := make([][]byte, 1)
+		errs := make([]error, 1)
+		if failed, err := d.DecryptBatchWebSafe(dst, errs, ciphers); err != nil || failed != 0 {
+			t.Fatal(err, errs)
+		}
+		if !bytes.Equal(dst[0], decryptedAdID) {
+			t.Error("decrypt web-safe batch failed")
+		}
+	})
+	t.Run("price", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		ciphers, prices := batchPrices(t, 100)
+		dst := make([]float64, len(ciphers))
+		errs := make([]error, len(ciphers))
+		failed, err := d.DecryptPriceBatch(dst, errs, ciphers, micros)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed != 33 {
+			t.Error("unexpected failed count", failed)
+		}
+		for i := range ciphers {
+			if i%3 == 2 {
+				if errs[i] != ErrSignCheckFail || dst[i] != 0 {
+					t.Error("expected error", ErrSignCheckFail)
+				}
+				continue
+			}
+			if errs[i] != nil || dst[i] != float64(prices[i])/float64(micros) {
+				t.Error("decrypt price batch failed", i, errs[i])
+			}
+		}
+	})
+	t.Run("price web-safe", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		ciphers := [][]byte{[]byte(base64.RawURLEncoding.EncodeToString(encryptedPrice)), []byte("*")}
+		dst := make([]float64, 2)
+		errs := make([]error, 2)
+		failed, err := d.DecryptPriceBatchWebSafe(dst, errs, ciphers, micros)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed != 1 || errs[0] != nil || errs[1] != ErrBadBase64 {
+			t.Error("unexpected errors", errs)
+		}
+		if d.enc != EncodingRaw {
+			t.Error("encoding not restored")
+		}
+	})
+	t.Run("parallel", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		ciphers, prices := batchPrices(t, 5000)
+		dst := make([]float64, len(ciphers))
+		errs := make([]error, len(ciphers))
+		failed, err := d.DecryptPriceBatchParallel(dst, errs, ciphers, micros, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed != 1666 {
+			t.Error("unexpected failed count", failed)
+		}
+		for i := range ciphers {
+			if (i%3 == 2) != (errs[i] != nil) || (errs[i] == nil && dst[i] != float64(prices[i])/float64(micros)) {
+				t.Error("order mismatch at", i)
+			}
+		}
+
+		bdst := make([][]byte, len(ciphers))
+		if failed, err = d.DecryptBatchParallel(bdst, errs, ciphers, 0); err != nil || failed != 1666 {
+			t.Error("unexpected parallel result", failed, err)
+		}
+		for i := range ciphers {
+			if errs[i] == nil && binary.BigEndian.Uint64(bdst[i]) != prices[i] {
+				t.Error("order mismatch at", i)
+			}
+		}
+	})
+	t.Run("bad args", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		ciphers := [][]byte{encryptedPrice, encryptedPrice}
+		if _, err := d.DecryptPriceBatch(make([]float64, 1), make([]error, 2), ciphers, micros); err != ErrBadBatchLen {
+			t.Error("expected error", ErrBadBatchLen)
+		}
+		if _, err := d.DecryptPriceBatch(make([]float64, 2), make([]error, 2), ciphers, 0); err != ErrBadMicros {
+			t.Error("expected error", ErrBadMicros)
+		}
+		if _, err := d.DecryptBatch(make([][]byte, 2), nil, ciphers); err != ErrBadBatchLen {
+			t.Error("expected error", ErrBadBatchLen)
+		}
+	})
+}
+
+func BenchmarkBatch(b *testing.B) {
+	ciphers, _ := batchPrices(b, 1024)
+	dst := make([]float64, len(ciphers))
+	errs := make([]error, len(ciphers))
+	b.Run("price", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = d.DecryptPriceBatch(dst, errs, ciphers, micros)
+		}
+	})
+	b.Run("encrypt price", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		prices := make([]uint64, len(ciphers))
+		initVecs := make([][]byte, len(ciphers))
+		for i := range ciphers {
+			initVecs[i] = ciphers[i][:initVectorLen]
+		}
+		out := make([][]byte, len(ciphers))
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = d.EncryptPriceMicrosBatch(out, errs, prices, initVecs)
+		}
+	})
+	b.Run("price parallel", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = d.DecryptPriceBatchParallel(dst, errs, ciphers, micros, 0)
+		}
+	})
+}
@@ -0,0 +1,259 @@
+package doubleclick
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+)
+
+// Size of stack buffer of decoded messages.
+const codecStackLen = 128
+
+// Codec is an immutable keyed encryption/decryption tool.
+//
+// Unlike DoubleClick it has no mutable state: scratch space is taken from the stack or from the tail of destination
+// array. Thus, codec may be shared between goroutines without pool. Options are applied by With* methods that return
+// modified copies.
+type Codec struct {
+	typ Type
+	// Encryption and integrity HMAC key schedules.
+	hmacE, hmacI hmacKey
+	// Decryption policy.
+	policy *Policy
+	// Rounding mode of float prices.
+	round Rounding
+	// Wire encoding of messages.
+	enc Encoding
+}
+
+// NewCodec makes new codec of given type and keys.
+func NewCodec(typ Type, encryptionKey, integrityKey []byte) *Codec {
+	return &Codec{
+		typ:   typ,
+		hmacE: newHMACKey(encryptionKey),
+		hmacI: newHMACKey(integrityKey),
+	}
+}
+
+// Type returns type of the codec.
+func (c *Codec) Type() Type {
+	return c.typ
+}
+
+// WithType returns copy of the codec with the same keys and options, but another type.
+func (c *Codec) WithType(typ Type) *Codec {
+	cpy := *c
+	cpy.typ = typ
+	return &cpy
+}
+
+// WithPolicy returns copy of the codec with decryption policy.
+func (c *Codec) WithPolicy(policy *Policy) *Codec {
+	cpy := *c
+	cpy.policy = policy
+	return &cpy
+}
+
+// WithRounding returns copy of the codec with rounding mode of float prices.
+func (c *Codec) WithRounding(round Rounding) *Codec {
+	cpy := *c
+	cpy.round = round
+	return &cpy
+}
+
+// WithEncoding returns copy of the codec with wire encoding of messages.
+func (c *Codec) WithEncoding(enc Encoding) *Codec {
+	cpy := *c
+	cpy.enc = enc
+	return &cpy
+}
+
+// Encrypt encrypts plain using initVec and appends message to dst.
+func (c *Codec) Encrypt(dst, initVec, plain []byte) ([]byte, error) {
+	return c.EncryptFn(dst, initVec, plain, nil)
+}
+
+// EncryptFn performs encryption and apply post-encryption convert func.
+//
+// Note that unlike DoubleClick.EncryptFn message is appended to dst.
+func (c *Codec) EncryptFn(dst, initVec, plain []byte, convFn ConvFn) ([]byte, error) {
+	ti, ok := lookupType(c.typ)
+	if !ok {
+		return dst, ErrUnkType
+	}
+
+	// Apply default convert func of the type; tail of dst is used as a scratch.
+	off := len(dst)
+	if ti.encFn != nil {
+		dst = ti.encFn(dst, plain)
+		plain = dst[off:]
+	}
+
+	plainLen := ti.payloadLen
+	if plainLen == 0 {
+		// Variable length payload.
+		plainLen = len(plain)
+	}
+	if plainLen == 0 || plainLen > maxPayloadLen || len(plain) != plainLen {
+		return dst[:off], ErrBadPlainLen
+	}
+	return c.encrypt(dst, off, initVec, plain, convFn)
+}
+
+// EncryptPrice encrypts price and appends message to dst.
+func (c *Codec) EncryptPrice(price float64, dst, initVec []byte, micros int) ([]byte, error) {
+	uprice, err := priceMicros(price, micros, c.round)
+	if err != nil {
+		return dst, err
+	}
+	return c.EncryptPriceMicros(uprice, dst, initVec)
+}
+
+// EncryptPriceMicros encrypts price already multiplied to micros.
+func (c *Codec) EncryptPriceMicros(price uint64, dst, initVec []byte) ([]byte, error) {
+	var plain [payloadLenPrice]byte
+	binary.BigEndian.PutUint64(plain[:], price)
+	return c.encrypt(dst, len(dst), initVec, plain[:], nil)
+}
+
+// Common encryption helper.
+//
+// Message is built after scratch data in dst[off:] and then moved to off position.
+func (c *Codec) encrypt(dst []byte, off int, initVec, plain []byte, convFn ConvFn) ([]byte, error) {
+	if len(initVec) != initVectorLen {
+		return dst[:off], ErrBadInitvLen
+	}
+
+	// Fill message: init vector, xor of plain and pads, signature.
+	start := len(dst)
+	dst = append(dst, initVec...)
+	dst = append(dst, plain...)
+	cipher := dst[start+initVectorLen:]
+	var sign [sha1Len]byte
+	encryptPads(&c.hmacE, &c.hmacI, cipher, &sign, plain, initVec)
+	dst = append(dst, sign[:integritySignLen]...)
+
+	// Apply wire encoding.
+	if c.enc != EncodingRaw {
+		end := len(dst)
+		dst = c.enc.Encode(dst, dst[start:end])
+		start = end
+	}
+
+	// Check and apply convert func.
+	if convFn != nil {
+		end := len(dst)
+		dst = convFn(dst, dst[start:end])
+		start = end
+	}
+
+	// Move result to the beginning of the scratch space.
+	return append(dst[:off], dst[start:]...), nil
+}
+
+// Decrypt decrypts cipher and appends payload to dst.
+func (c *Codec) Decrypt(dst, cipher []byte) ([]byte, error) {
+	return c.DecryptFn(dst, cipher, nil)
+}
+
+// DecryptFn performs decryption and apply post-decryption convert func.
+func (c *Codec) DecryptFn(dst, cipher []byte, convFn ConvFn) ([]byte, error) {
+	// Apply wire encoding.
+	var buf [codecStackLen]byte
+	if c.enc != EncodingRaw {
+		var err error
+		if cipher, err = c.enc.Decode(buf[:0], cipher); err != nil {
+			return dst, err
+		}
+	}
+
+	ti, ok := lookupType(c.typ)
+	if !ok {
+		return dst, ErrUnkType
+	}
+	payloadLen := ti.payloadLen
+	if payloadLen == 0 {
+		// Variable length payload.
+		payloadLen = len(cipher) - msgOverhead
+	}
+	if payloadLen <= 0 || payloadLen > maxPayloadLen || len(cipher) != payloadLen+msgOverhead {
+		return dst, ErrBadMsgLen
+	}
+
+	// Decrypt payload to the tail of dst.
+	off := len(dst)
+	dst = append(dst, cipher[cipherOffset:cipherOffset+payloadLen]...)
+	if err := c.decrypt(dst[off:], cipher, payloadLen); err != nil {
+		return dst[:off], err
+	}
+
+	// Use default convert func of the type.
+	if convFn == nil {
+		convFn = ti.decFn
+	}
+	if convFn != nil {
+		end := len(dst)
+		dst = convFn(dst, dst[off:end])
+		dst = append(dst[:off], dst[end:]...)
+	}
+	return dst, nil
+}
+
+// DecryptPrice decrypts price.
+func (c *Codec) DecryptPrice(cipher []byte, micros int) (float64, error) {
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	price, err := c.DecryptPriceMicros(cipher)
+	if err != nil {
+		return 0, err
+	}
+	return float64(price) / float64(micros), nil
+}
+
+// DecryptPriceMicros decrypts price without division to micros.
+func (c *Codec) DecryptPriceMicros(cipher []byte) (uint64, error) {
+	// Apply wire encoding.
+	var buf [codecStackLen]byte
+	if c.enc != EncodingRaw {
+		var err error
+		if cipher, err = c.enc.Decode(buf[:0], cipher); err != nil {
+			return 0, err
+		}
+	}
+	if len(cipher) != msgLenPrice {
+		return 0, ErrBadMsgLen
+	}
+
+	var payload [payloadLenPrice]byte
+	if err := c.decrypt(payload[:], cipher, payloadLenPrice); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(payload[:]), nil
+}
+
+// Common decryption helper.
+//
+// Decrypts cipher to payload and checks signature and decryption policy.
+func (c *Codec) decrypt(payload, cipher []byte, payloadLen int) error {
+	// Split message to parts (init vector, payload, integrity sign).
+	initVector := cipher[initVectorOffset:initVectorLen]
+	cipherText := cipher[cipherOffset : cipherOffset+payloadLen]
+	integritySignOffset := cipherOffset + payloadLen
+	integritySign := cipher[integritySignOffset : integritySignOffset+integritySignLen]
+
+	// Apply xor to reverse encryption.
+	xorPads(&c.hmacE, payload, cipherText, initVector)
+
+	// Compute signature.
+	var sign [sha1Len]byte
+	c.hmacI.sumTo(&sign, payload, initVector)
+	if !hmac.Equal(sign[:integritySignLen], integritySign) {
+		return ErrSignCheckFail
+	}
+
+	// Check decryption policy.
+	if c.policy != nil {
+		return c.policy.check(initVector, integritySign)
+	}
+	return nil
+}
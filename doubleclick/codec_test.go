@@ -0,0 +1,178 @@
+package doubleclick
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCodec(t *testing.T) {
+	t.Run("adid", func(t *testing.T) {
+		c := NewCodec(TypeAdID, encryptionKey, integrityKey)
+		dst, err := c.Encrypt(nil, initVector, decryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedAdID) {
+			t.Error("encrypt AdID failed")
+		}
+		if dst, err = c.DecryptFn(dst[:0], encryptedAdID, ConvPayloadToUUID); err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, decryptedAdUUID) {
+			t.Error("decrypt AdID failed")
+		}
+	})
+	t.Run("append", func(t *testing.T) {
+		c := NewCodec(TypeIDFA, encryptionKey, integrityKey)
+		dst, err := c.Encrypt([]byte("prefix"), initVector, decryptedIDFA)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, append([]byte("prefix"), encryptedIDFA...)) {
+			t.Error("encrypt IDFA failed")
+		}
+		if dst, err = c.Decrypt(dst[:6], encryptedIDFA); err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, append([]byte("prefix"), decryptedIDFA...)) {
+			t.Error("decrypt IDFA failed")
+		}
+		if dst, err = c.Decrypt(dst[:6], encryptedAdID); err != ErrBadMsgLen || string(dst) != "prefix" {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+	})
+	t.Run("price", func(t *testing.T) {
+		c := NewCodec(TypeAdID, encryptionKey, integrityKey)
+		dst, err := c.EncryptPrice(decryptedPrice, nil, initVector, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedPrice) {
+			t.Error("encrypt price failed")
+		}
+		price, err := c.DecryptPrice(encryptedPrice, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+		if _, err = c.DecryptPrice(encryptedPrice, 0); err != ErrBadMicros {
+			t.Error("expected error", ErrBadMicros)
+		}
+		if _, err = c.EncryptPrice(-1, nil, initVector, micros); err != ErrBadPrice {
+			t.Error("expected error", ErrBadPrice)
+		}
+	})
+	t.Run("options", func(t *testing.T) {
+		c := NewCodec(TypePrice, encryptionKey, integrityKey)
+		h := c.WithEncoding(EncodingHex)
+		dst, err := h.EncryptPriceMicros(1200000, nil, initVector)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(dst) != hex.EncodeToString(encryptedPrice) {
+			t.Error("encrypt hex price failed")
+		}
+		if price, err := h.DecryptPriceMicros(dst); err != nil || price != 1200000 {
+			t.Error("decrypt hex price failed", err)
+		}
+		if c.enc != EncodingRaw {
+			t.Error("origin codec changed")
+		}
+
+		r := c.WithType(TypeRaw)
+		plain := bytes.Repeat([]byte("raw"), 20)
+		if dst, err = r.Encrypt(nil, initVector, plain); err != nil {
+			t.Error(err)
+		}
+		if dst, err = r.Decrypt(nil, dst); err != nil || !bytes.Equal(dst, plain) {
+			t.Error("raw round trip failed", err)
+		}
+
+		p := c.WithPolicy(&Policy{MaxAge: time.Hour, Clock: func() time.Time { return initVectorTs.Add(2 * time.Hour) }})
+		if _, err = p.DecryptPrice(encryptedPrice, micros); err != ErrExpired {
+			t.Error("expected error", ErrExpired)
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		c := NewCodec(TypeAdID, encryptionKey, integrityKey)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var dst []byte
+				for j := 0; j < 100; j++ {
+					var err error
+					if dst, err = c.DecryptFn(dst[:0], encryptedAdID, ConvPayloadToUUID); err != nil {
+						t.Error(err)
+					}
+					if !bytes.Equal(dst, decryptedAdUUID) {
+						t.Error("concurrent decrypt failed")
+					}
+					if _, err = c.DecryptPrice(encryptedPrice, micros); err != nil {
+						t.Error(err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkCodec(b *testing.B) {
+	b.Run("decrypt price", func(b *testing.B) {
+		c := NewCodec(TypePrice, encryptionKey, integrityKey)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			price, err := c.DecryptPrice(encryptedPrice, micros)
+			if err != nil || price != decryptedPrice {
+				b.Error("decrypt price failed")
+			}
+		}
+	})
+	b.Run("encrypt price", func(b *testing.B) {
+		c := NewCodec(TypePrice, encryptionKey, integrityKey)
+		var dst []byte
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst, _ = c.EncryptPrice(decryptedPrice, dst[:0], initVector, micros)
+		}
+	})
+	b.Run("decrypt adid", func(b *testing.B) {
+		c := NewCodec(TypeAdID, encryptionKey, integrityKey)
+		var dst []byte
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst, _ = c.DecryptFn(dst[:0], encryptedAdID, ConvPayloadToUUID)
+		}
+	})
+	b.Run("decrypt price parallel", func(b *testing.B) {
+		c := NewCodec(TypePrice, encryptionKey, integrityKey)
+		b.ResetTimer()
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = c.DecryptPrice(encryptedPrice, micros)
+			}
+		})
+	})
+	b.Run("decrypt price pool", func(b *testing.B) {
+		b.ResetTimer()
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				d := Acquire(TypePrice, encryptionKey, integrityKey)
+				_, _ = d.DecryptPrice(encryptedPrice, micros)
+				Release(d)
+			}
+		})
+	})
+}
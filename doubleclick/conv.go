@@ -2,7 +2,8 @@ package doubleclick
 
 const (
 	// Hex symbols.
-	hextable = "0123456789abcdef"
+	hextable      = "0123456789abcdef"
+	hextableUpper = "0123456789ABCDEF"
 	// UUID dash positions.
 	dashPosTimeLow      = 4
 	dashPosTimeMid      = 6
@@ -26,3 +27,12 @@ func ConvPayloadToUUID(dst, src []byte) []byte {
 	}
 	return dst
 }
+
+// ConvPayloadToHex converts payload to hex string.
+func ConvPayloadToHex(dst, src []byte) []byte {
+	for i := 0; i < len(src); i++ {
+		dst = append(dst, hextable[src[i]>>4])
+		dst = append(dst, hextable[src[i]&0x0f])
+	}
+	return dst
+}
@@ -0,0 +1,96 @@
+package doubleclick
+
+import (
+	"math"
+	"strconv"
+)
+
+// Max supported decimal scale (uint64 fits up to 19 decimal digits).
+const decimalMaxScale = 19
+
+var pow10tab = [decimalMaxScale + 1]uint64{
+	1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+}
+
+// Decimal is a fixed-point decimal number equal to Value * 10^-Scale.
+//
+// Decrypted prices represented as decimals keep exact micros value and don't suffer from float64 rounding.
+type Decimal struct {
+	Value uint64
+	Scale uint8
+}
+
+// Float64 returns float representation of the decimal.
+func (x Decimal) Float64() float64 {
+	return float64(x.Value) / math.Pow10(int(x.Scale))
+}
+
+// AppendString appends decimal string representation with given count of fractional digits to dst.
+//
+// Extra digits are rounded half up, missing digits are padded by zeros.
+func (x Decimal) AppendString(dst []byte, digits int) []byte {
+	if digits < 0 {
+		digits = 0
+	}
+	scale := int(x.Scale)
+	var (
+		v   = x.Value
+		pad int
+	)
+	if digits < scale {
+		// Round half up. Carry may increase integer part. Value has at most 20 digits, so rounding of more digits
+		// gives zero.
+		v = 0
+		if n := scale - digits; n <= decimalMaxScale {
+			p := pow10tab[n]
+			q, r := x.Value/p, x.Value%p
+			if r >= p-r {
+				q++
+			}
+			v = q
+		}
+		scale = digits
+	} else {
+		pad = digits - scale
+	}
+	// Integer part is zero for scales exceeding max uint64 length.
+	ipart, frac := uint64(0), v
+	if scale <= decimalMaxScale {
+		p := pow10tab[scale]
+		ipart, frac = v/p, v%p
+	}
+	dst = strconv.AppendUint(dst, ipart, 10)
+	if digits == 0 {
+		return dst
+	}
+	dst = append(dst, '.')
+	if scale > 0 {
+		// Leading zeros of fractional part.
+		for i := scale - 1; i > 0 && (i > decimalMaxScale || frac < pow10tab[i]); i-- {
+			dst = append(dst, '0')
+		}
+		dst = strconv.AppendUint(dst, frac, 10)
+	}
+	for i := 0; i < pad; i++ {
+		dst = append(dst, '0')
+	}
+	return dst
+}
+
+// String returns decimal string representation with all fractional digits.
+func (x Decimal) String() string {
+	return string(x.AppendString(nil, int(x.Scale)))
+}
+
+// DecryptPriceDecimal decrypts price to fixed-point decimal with given scale, e.g. 6 for micros.
+func (d *DoubleClick) DecryptPriceDecimal(cipher []byte, scale int) (Decimal, error) {
+	if scale < 0 || scale > decimalMaxScale {
+		return Decimal{}, ErrBadScale
+	}
+	price, err := d.DecryptPriceMicros(cipher)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{Value: price, Scale: uint8(scale)}, nil
+}
@@ -0,0 +1,176 @@
+package doubleclick
+
+import "encoding/binary"
+
+// DecryptAny detects type of the message by its length and decrypts it to dst.
+//
+// Message may be raw or web-safe base64 encoded (or encoded using wire encoding of the instance). Candidate types may
+// be limited by types param, otherwise all registered fixed length types will be checked. Note that IDFA and price
+// messages have the same length, so they can't be distinguished without limiting candidates and ErrAmbiguousType will
+// be returned.
+//
+// Returns detected type as second value.
+func (d *DoubleClick) DecryptAny(dst, msg []byte, types ...Type) ([]byte, Type, error) {
+	// Apply wire encoding.
+	if d.enc != EncodingRaw {
+		var err error
+		if d.wbuf, err = d.enc.Decode(d.wbuf[:0], msg); err != nil {
+			return dst, 0, err
+		}
+		msg = d.wbuf
+	}
+	typ, err := detectType(len(msg), types)
+	if err == ErrUnkMsgType && d.enc == EncodingRaw && isBase64(msg) {
+		// Try to decode web-safe message.
+		if d.wbuf, err = b64DecodeAny(d.wbuf[:0], msg); err != nil {
+			return dst, typ, err
+		}
+		msg = d.wbuf
+		typ, err = detectType(len(msg), types)
+	}
+	if err != nil {
+		return dst, typ, err
+	}
+
+	// Temporarily switch type and decrypt.
+	origTyp := d.typ
+	d.typ = typ
+	dst, err = d.decryptFn(dst, msg, nil)
+	d.typ = origTyp
+	return dst, typ, err
+}
+
+// Find the only type among types (or all registered types if omitted) with given message length.
+func detectType(msgLen int, types []Type) (Type, error) {
+	var (
+		typ   Type
+		found bool
+	)
+	all := registry.Load().([]typeInfo)
+	n := len(types)
+	if n == 0 {
+		n = len(all)
+	}
+	for i := 0; i < n; i++ {
+		t := Type(i)
+		if len(types) > 0 {
+			t = types[i]
+		}
+		ti, ok := lookupType(t)
+		if !ok || ti.payloadLen == 0 || ti.payloadLen+msgOverhead != msgLen || (found && t == typ) {
+			continue
+		}
+		if found {
+			return typ, ErrAmbiguousType
+		}
+		typ, found = t, true
+	}
+	if !found {
+		return typ, ErrUnkMsgType
+	}
+	return typ, nil
+}
+
+// DecryptDetect detects wire encoding of the message and decrypts it to dst.
+//
+// Supports raw, web-safe base64 (with or without paddings), standard base64, percent-escaped base64 and hex
+// encodings. Message is normalized to the internal buffer, so no allocations are performed after warm-up.
+//
+// Returns detected encoding as second value.
+func (d *DoubleClick) DecryptDetect(dst, msg []byte) ([]byte, Encoding, error) {
+	return d.DecryptDetectFn(dst, msg, nil)
+}
+
+// DecryptDetectFn detects wire encoding of the message, decrypts it to dst and apply post-decryption convert func.
+//
+// Returns detected encoding as second value.
+func (d *DoubleClick) DecryptDetectFn(dst, msg []byte, convFn ConvFn) ([]byte, Encoding, error) {
+	var msgLen int
+	if ti, ok := lookupType(d.typ); ok && ti.payloadLen > 0 {
+		msgLen = ti.payloadLen + msgOverhead
+	}
+	enc := detectEncoding(msg, msgLen)
+	if enc != EncodingRaw {
+		var err error
+		if d.wbuf, err = enc.Decode(d.wbuf[:0], msg); err != nil {
+			return dst, enc, err
+		}
+		msg = d.wbuf
+	}
+	dst, err := d.decryptFn(dst, msg, convFn)
+	return dst, enc, err
+}
+
+// DecryptPriceDetect detects wire encoding of the message and decrypts price.
+//
+// Returns detected encoding as second value.
+func (d *DoubleClick) DecryptPriceDetect(msg []byte, micros int) (float64, Encoding, error) {
+	if micros <= 0 {
+		return 0, EncodingRaw, ErrBadMicros
+	}
+	// Increase buffer with +1 payload length and use extra space as a destination array.
+	bufLen := bufPadLen + payloadLenPrice + bufSignLen
+	doubleBufLen := bufLen + payloadLenPrice
+	if len(d.buf) < doubleBufLen {
+		d.buf = append(d.buf, make([]byte, doubleBufLen-len(d.buf))...)
+	}
+
+	decrypted, enc, err := d.DecryptDetectFn(d.buf[bufLen:bufLen], msg, nil)
+	if err != nil {
+		return 0, enc, err
+	}
+	if len(decrypted) != payloadLenPrice {
+		return 0, enc, ErrBadMsgLen
+	}
+	return float64(binary.BigEndian.Uint64(decrypted)) / float64(micros), enc, nil
+}
+
+// Detect wire encoding of the message.
+//
+// Expected length of raw message is msgLen, zero means variable length.
+func detectEncoding(msg []byte, msgLen int) Encoding {
+	if msgLen > 0 && len(msg) == msgLen {
+		return EncodingRaw
+	}
+	var (
+		hex          = len(msg)%2 == 0
+		b64          = len(msg) > 0
+		pct, ws, std bool
+		pad          bool
+	)
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		case c >= 'g' && c <= 'z', c >= 'G' && c <= 'Z':
+			hex = false
+		case c == '-' || c == '_':
+			hex, ws = false, true
+		case c == '+' || c == '/':
+			hex, std = false, true
+		case c == '=':
+			hex, pad = false, true
+		case c == '%':
+			hex, pct = false, true
+		default:
+			// Binary data.
+			return EncodingRaw
+		}
+	}
+	switch {
+	case pct:
+		return EncodingPercent
+	case hex && (msgLen == 0 || len(msg) == msgLen*2):
+		return EncodingHex
+	case ws && pad:
+		return EncodingWebSafePadded
+	case ws:
+		return EncodingWebSafe
+	case std || pad:
+		return EncodingBase64
+	case b64:
+		return EncodingWebSafe
+	default:
+		return EncodingRaw
+	}
+}
@@ -0,0 +1,130 @@
+package doubleclick
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDecryptAny(t *testing.T) {
+	stages := []struct {
+		msg    []byte
+		types  []Type
+		typ    Type
+		expect []byte
+	}{
+		{msg: encryptedAdID, typ: TypeAdID, expect: decryptedAdID},
+		{msg: encryptedHyperlocal, typ: TypeHyperlocal, expect: decryptedHyperlocal},
+		{msg: encryptedIDFA, types: []Type{TypeAdID, TypeIDFA}, typ: TypeIDFA, expect: decryptedIDFA},
+		{msg: encryptedPrice, types: []Type{TypePrice}, typ: TypePrice, expect: encryptedPrice[:0]},
+	}
+	d := New(TypePrice, encryptionKey, integrityKey)
+	for _, st := range stages {
+		dst, typ, err := d.DecryptAny(nil, st.msg, st.types...)
+		if err != nil {
+			t.Error(err)
+		}
+		if typ != st.typ {
+			t.Error("type mismatch, got", typ)
+		}
+		if typ != TypePrice && !bytes.Equal(dst, st.expect) {
+			t.Error("decrypt any failed")
+		}
+		ws := []byte(base64.RawURLEncoding.EncodeToString(st.msg))
+		dst1, typ, err := d.DecryptAny(nil, ws, st.types...)
+		if err != nil {
+			t.Error(err)
+		}
+		if typ != st.typ || !bytes.Equal(dst, dst1) {
+			t.Error("decrypt any web-safe failed")
+		}
+	}
+	if d.typ != TypePrice {
+		t.Error("type must be restored")
+	}
+	if _, _, err := d.DecryptAny(nil, encryptedPrice); err != ErrAmbiguousType {
+		t.Error("expected error", ErrAmbiguousType)
+	}
+	if _, _, err := d.DecryptAny(nil, encryptedPrice[:20]); err != ErrUnkMsgType {
+		t.Error("expected error", ErrUnkMsgType)
+	}
+}
+
+func TestDecryptDetect(t *testing.T) {
+	std := base64.StdEncoding.EncodeToString(encryptedPrice)
+	stages := []struct {
+		msg []byte
+		enc Encoding
+	}{
+		{encryptedPrice, EncodingRaw},
+		{[]byte(base64.RawURLEncoding.EncodeToString(encryptedPrice)), EncodingWebSafe},
+		{[]byte(base64.URLEncoding.EncodeToString(encryptedPrice)), EncodingWebSafePadded},
+		{[]byte(std), EncodingBase64},
+		{[]byte(url.QueryEscape(std)), EncodingPercent},
+		{[]byte(hex.EncodeToString(encryptedPrice)), EncodingHex},
+		{[]byte(strings.ToUpper(hex.EncodeToString(encryptedPrice))), EncodingHex},
+	}
+	d := New(TypePrice, encryptionKey, integrityKey)
+	for _, st := range stages {
+		price, enc, err := d.DecryptPriceDetect(st.msg, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if enc != st.enc {
+			t.Errorf("encoding mismatch: expect %s, got %s", st.enc, enc)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+	}
+	t.Run("raw type", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		for _, enc := range []Encoding{EncodingRaw, EncodingWebSafe, EncodingHex, EncodingPercent} {
+			dst, enc1, err := d.DecryptDetect(nil, enc.Encode(nil, encryptedRaw))
+			if err != nil {
+				t.Error(err)
+			}
+			if enc1 != enc {
+				t.Errorf("encoding mismatch: expect %s, got %s", enc, enc1)
+			}
+			if !bytes.Equal(dst, decryptedRaw) {
+				t.Error("decrypt raw failed")
+			}
+		}
+	})
+}
+
+func BenchmarkDecryptAny(b *testing.B) {
+	b.Run("detect", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		msg := []byte(url.QueryEscape(base64.StdEncoding.EncodeToString(encryptedPrice)))
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			price, _, err := d.DecryptPriceDetect(msg, micros)
+			if err != nil {
+				b.Error(err)
+			}
+			if price != decryptedPrice {
+				b.Error("decrypt price failed")
+			}
+		}
+	})
+	b.Run("web-safe", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		ws := []byte(base64.RawURLEncoding.EncodeToString(encryptedAdID))
+		var dst []byte
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var err error
+			if dst, _, err = d.DecryptAny(dst[:0], ws); err != nil {
+				b.Error(err)
+			}
+			d.Reset()
+		}
+	})
+}
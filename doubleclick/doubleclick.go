@@ -3,10 +3,8 @@ package doubleclick
 import (
 	"bytes"
 	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/base64"
 	"encoding/binary"
-	"hash"
+	"math"
 )
 
 // Type is a type constant of supported DoubleClick types.
@@ -25,6 +23,8 @@ const (
 	// TypeHyperlocal is a Hyperlocal Targeting Signals
 	// https://developers.google.com/authorized-buyers/rtb/response-guide/decrypt-hyperlocal
 	TypeHyperlocal
+	// TypeRaw is a variable length payload encrypted using counter-based multi-block pads.
+	TypeRaw
 
 	// Message bounds
 	initVectorOffset = 0
@@ -49,6 +49,13 @@ const (
 	// Price message and payload length
 	msgLenPrice     = 28
 	payloadLenPrice = 8
+	// Message overhead (init vector and integrity signature) of variable length payloads.
+	msgOverhead = initVectorLen + integritySignLen
+	// Max length of variable payload: pad counter takes up to 3 bytes, so 1 + 3*256 blocks are available.
+	maxPayloadLen = bufPadLen * (1 + 3*256)
+
+	// Float representation of 2^64 (the first value that overflows uint64).
+	maxUint64Float = 1 << 64
 )
 
 // DoubleClick is an encryption and decryption support for the DoubleClick Ad Exchange RTB protocol.
@@ -57,20 +64,41 @@ const (
 // initVector:16 || E(payload:?) || I(signature:4)
 // where:
 // * initVector = timestamp:8 || serverId:8} (AdX convention)
-// * E(payload) = payload ^ hmac(encryptionKey, initVector)} per max-20-byte block
+// * E(payload) = payload ^ hmac(encryptionKey, initVector || counter)} per max-20-byte block,
+// counter is omitted for the first block and then counts 0x00, 0x01, ... growing by a leading zero byte on wrap
 // * I(signature) = hmac(integrityKey, payload || initVector)[0..3]}
 //
 // This tool is thread-safe when use it together with pool.
 type DoubleClick struct {
 	typ Type
-	// Encryption and integrity HMAC instances.
-	hmacE, hmacI hash.Hash
+	// Copies of encryption and integrity keys.
+	ekey, ikey []byte
+	// Encryption and integrity HMAC key schedules.
+	hmacE, hmacI hmacKey
+	keyed        bool
 	// Byte buffer.
 	buf []byte
+	// Buffer of decoded input messages.
+	wbuf []byte
+	// Array of decoded web-safe messages of fixed length types, see DecryptWebSafe.
+	warr [wsArrayLen]byte
+	// Buffer of converted plain sources.
+	pbuf []byte
+	// Init vector generator and buffer of generated init vector.
+	ivgen *InitVectorGen
+	iv    [initVectorLen]byte
+	// Decryption policy.
+	policy *Policy
+	// Rounding mode of float prices.
+	round Rounding
+	// Wire encoding of messages.
+	enc Encoding
+	// Scratch space of batch methods.
+	bscr *batchScratch
+	// Free list of keyed pool the instance belongs to.
+	list *keyedList
 }
 
-var b64Pad = []byte("=")
-
 // New makes new instance of DoubleClick.
 //
 // Better use pool instead of direct using New().
@@ -80,18 +108,60 @@ func New(typ Type, encryptionKey, integrityKey []byte) *DoubleClick {
 	return d
 }
 
+// NewFromStrings makes new instance of DoubleClick using keys in one of the supported formats (see ParseKey).
+//
+// Unlike New, keys are validated, so broken key material is reported immediately instead of ErrSignCheckFail on
+// decryption.
+func NewFromStrings(typ Type, encryptionKey, integrityKey string) (*DoubleClick, error) {
+	kp, err := ParseKeyPair("", encryptionKey, integrityKey)
+	if err != nil {
+		return nil, err
+	}
+	return New(typ, kp.EncryptionKey, kp.IntegrityKey), nil
+}
+
 // SetKeys sets encryption and integrity keys.
 //
-// During first keys set will be initialized HMAC helpers.
+// HMAC key schedules will be rebuilt every time when keys differ from the previous ones. Setting the same keys again
+// keeps current schedules.
 func (d *DoubleClick) SetKeys(encryptionKey, integrityKey []byte) {
 	// Init encryption hmac.
-	if d.hmacE == nil {
-		d.hmacE = hmac.New(sha1.New, encryptionKey)
+	if !d.keyed || !bytes.Equal(d.ekey, encryptionKey) {
+		d.ekey = append(d.ekey[:0], encryptionKey...)
+		d.hmacE = newHMACKey(d.ekey).owned()
 	}
 	// Init integrity hmac.
-	if d.hmacI == nil {
-		d.hmacI = hmac.New(sha1.New, integrityKey)
+	if !d.keyed || !bytes.Equal(d.ikey, integrityKey) {
+		d.ikey = append(d.ikey[:0], integrityKey...)
+		d.hmacI = newHMACKey(d.ikey).owned()
 	}
+	d.keyed = true
+}
+
+// SetInitVectorGen sets init vector generator for auto encryption methods.
+//
+// If generator isn't set, the default generator with random server ID will be used.
+func (d *DoubleClick) SetInitVectorGen(gen *InitVectorGen) {
+	d.ivgen = gen
+}
+
+// SetPolicy sets decryption policy.
+//
+// Nil policy disables all checks.
+func (d *DoubleClick) SetPolicy(policy *Policy) {
+	d.policy = policy
+}
+
+// SetRounding sets rounding mode of float price to integer micros conversion.
+func (d *DoubleClick) SetRounding(round Rounding) {
+	d.round = round
+}
+
+// SetEncoding sets wire encoding of messages.
+//
+// Encoding applies automatically to encryption output and decryption input.
+func (d *DoubleClick) SetEncoding(enc Encoding) {
+	d.enc = enc
 }
 
 // Encrypt is a common encryption method.
@@ -103,20 +173,25 @@ func (d *DoubleClick) Encrypt(dst, initVec, plain []byte) ([]byte, error) {
 
 // EncryptFn performs encryption and apply post-encryption convert func.
 func (d *DoubleClick) EncryptFn(dst, initVec, plain []byte, convFn ConvFn) ([]byte, error) {
-	var plainLen int
-	switch d.typ {
-	case TypeAdID:
-		plainLen = payloadLenAdID
-	case TypeIDFA:
-		plainLen = payloadLenIDFA
-	case TypePrice:
-		plainLen = payloadLenPrice
-	case TypeHyperlocal:
-		plainLen = payloadLenHyperlocal
-	default:
+	ti, ok := lookupType(d.typ)
+	if !ok {
 		return dst, ErrUnkType
 	}
 
+	// Apply default convert func of the type.
+	if ti.encFn != nil {
+		d.pbuf = ti.encFn(d.pbuf[:0], plain)
+		plain = d.pbuf
+	}
+
+	plainLen := ti.payloadLen
+	if plainLen == 0 {
+		// Variable length payload.
+		if plainLen = len(plain); plainLen == 0 || plainLen > maxPayloadLen {
+			return dst, ErrBadPlainLen
+		}
+	}
+
 	if len(plain) != plainLen {
 		return dst, ErrBadPlainLen
 	}
@@ -128,8 +203,34 @@ func (d *DoubleClick) EncryptFn(dst, initVec, plain []byte, convFn ConvFn) ([]by
 //
 // See https://developers.google.com/authorized-buyers/rtb/response-guide/decrypt-price for details.
 func (d *DoubleClick) EncryptPrice(price float64, dst, initVec []byte, micros int) ([]byte, error) {
+	uprice, err := priceMicros(price, micros, d.round)
+	if err != nil {
+		return dst, err
+	}
+	return d.EncryptPriceMicros(uprice, dst, initVec)
+}
+
+// Check price and convert it to micros using rounding mode.
+func priceMicros(price float64, micros int, round Rounding) (uint64, error) {
+	// Check input.
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	if math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+		return 0, ErrBadPrice
+	}
 	// Apply micros.
-	uprice := uint64(price * float64(micros))
+	fprice := round.apply(price * float64(micros))
+	if fprice >= maxUint64Float {
+		return 0, ErrPriceOverflow
+	}
+	return uint64(fprice), nil
+}
+
+// EncryptPriceMicros encrypts price already multiplied to micros.
+//
+// Allows to avoid float64 rounding issues.
+func (d *DoubleClick) EncryptPriceMicros(price uint64, dst, initVec []byte) ([]byte, error) {
 	// Increase buffer with +1 payload length and use extra space as an intermediate source array.
 	bufLen := bufPadLen + payloadLenPrice + bufSignLen
 	doubleBufLen := bufLen + payloadLenPrice
@@ -138,12 +239,47 @@ func (d *DoubleClick) EncryptPrice(price float64, dst, initVec []byte, micros in
 	}
 	// Source buffer.
 	bprice := d.buf[bufLen:doubleBufLen]
-	binary.BigEndian.PutUint64(bprice, uprice)
+	binary.BigEndian.PutUint64(bprice, price)
 
 	d.typ = TypePrice
 	return d.EncryptFn(dst, initVec, bprice, nil)
 }
 
+// EncryptAuto encrypts plain to dst using generated init vector.
+func (d *DoubleClick) EncryptAuto(dst, plain []byte) ([]byte, error) {
+	return d.EncryptAutoFn(dst, plain, nil)
+}
+
+// EncryptAutoFn encrypts plain to dst using generated init vector and apply post-encryption convert func.
+func (d *DoubleClick) EncryptAutoFn(dst, plain []byte, convFn ConvFn) ([]byte, error) {
+	initVec, err := d.genInitVector()
+	if err != nil {
+		return dst, err
+	}
+	return d.EncryptFn(dst, initVec, plain, convFn)
+}
+
+// EncryptPriceAuto encrypts price using generated init vector.
+func (d *DoubleClick) EncryptPriceAuto(price float64, dst []byte, micros int) ([]byte, error) {
+	initVec, err := d.genInitVector()
+	if err != nil {
+		return dst, err
+	}
+	return d.EncryptPrice(price, dst, initVec, micros)
+}
+
+// Generate init vector to the internal buffer.
+func (d *DoubleClick) genInitVector() ([]byte, error) {
+	gen := d.ivgen
+	if gen == nil {
+		var err error
+		if gen, err = defaultInitVectorGen(); err != nil {
+			return nil, err
+		}
+	}
+	return gen.Generate(d.iv[:0]), nil
+}
+
 // Common encryption helper.
 func (d *DoubleClick) encrypt(dst, initVec, plain []byte, plainLen int, convFn ConvFn) ([]byte, error) {
 	// Check init vector length.
@@ -157,30 +293,21 @@ func (d *DoubleClick) encrypt(dst, initVec, plain []byte, plainLen int, convFn C
 		d.buf = append(d.buf, make([]byte, bufLen-len(d.buf))...)
 	}
 
-	// Compute pad.
-	pad := d.buf[bufPadOffset:bufPadLen]
-	d.hmacE.Reset()
-	d.hmacE.Write(initVec)
-	pad = d.hmacE.Sum(pad[:0])[:initVectorLen]
-
-	// Apply xor to do encryption.
+	// Apply xor to do encryption and compute signature.
 	cipher := d.buf[bufPayloadOffset : bufPayloadOffset+plainLen]
-	for i := 0; i < plainLen; i++ {
-		cipher[i] = pad[i] ^ plain[i]
-	}
-
-	// Compute signature.
-	bufSignOffset := bufPayloadOffset + plainLen
-	computedSign := d.buf[bufSignOffset : bufSignOffset+bufSignLen]
-	d.hmacI.Reset()
-	d.hmacI.Write(plain)
-	d.hmacI.Write(initVec)
-	computedSign = d.hmacI.Sum(computedSign[:0])[:integritySignLen]
+	var sign [sha1Len]byte
+	encryptPads(&d.hmacE, &d.hmacI, cipher, &sign, plain, initVec)
 
 	// Fill destination array.
 	dst = append(dst[:0], initVec...)
 	dst = append(dst, cipher...)
-	dst = append(dst, computedSign...)
+	dst = append(dst, sign[:integritySignLen]...)
+
+	// Apply wire encoding.
+	if d.enc != EncodingRaw {
+		d.wbuf = append(d.wbuf[:0], dst...)
+		dst = d.enc.Encode(dst[:0], d.wbuf)
+	}
 
 	// Check and apply convert func.
 	if convFn != nil {
@@ -200,30 +327,41 @@ func (d *DoubleClick) Decrypt(dst, cipher []byte) ([]byte, error) {
 
 // DecryptFn performs decryption and apply post-decryption convert func.
 func (d *DoubleClick) DecryptFn(dst, cipher []byte, convFn ConvFn) ([]byte, error) {
-	var (
-		msgLen, payloadLen int
-	)
-	switch d.typ {
-	case TypeAdID:
-		msgLen = msgLenAdID
-		payloadLen = payloadLenAdID
-	case TypeIDFA:
-		msgLen = msgLenIDFA
-		payloadLen = payloadLenIDFA
-	case TypePrice:
-		msgLen = msgLenPrice
-		payloadLen = payloadLenPrice
-	case TypeHyperlocal:
-		msgLen = msgLenHyperlocal
-		payloadLen = payloadLenHyperlocal
-	default:
+	// Apply wire encoding.
+	if d.enc != EncodingRaw {
+		var err error
+		if d.wbuf, err = d.enc.Decode(d.wbuf[:0], cipher); err != nil {
+			return dst, err
+		}
+		cipher = d.wbuf
+	}
+	return d.decryptFn(dst, cipher, convFn)
+}
+
+// Decryption helper of decoded messages.
+func (d *DoubleClick) decryptFn(dst, cipher []byte, convFn ConvFn) ([]byte, error) {
+	ti, ok := lookupType(d.typ)
+	if !ok {
 		return dst, ErrUnkType
 	}
 
-	if len(cipher) != msgLen {
+	payloadLen := ti.payloadLen
+	if payloadLen == 0 {
+		// Variable length payload.
+		if payloadLen = len(cipher) - msgOverhead; payloadLen <= 0 || payloadLen > maxPayloadLen {
+			return dst, ErrBadMsgLen
+		}
+	}
+
+	if len(cipher) != payloadLen+msgOverhead {
 		return dst, ErrBadMsgLen
 	}
 
+	// Use default convert func of the type.
+	if convFn == nil {
+		convFn = ti.decFn
+	}
+
 	return d.decrypt(dst, cipher, payloadLen, convFn)
 }
 
@@ -231,6 +369,20 @@ func (d *DoubleClick) DecryptFn(dst, cipher []byte, convFn ConvFn) ([]byte, erro
 //
 // See https://developers.google.com/authorized-buyers/rtb/response-guide/decrypt-price for details.
 func (d *DoubleClick) DecryptPrice(cipher []byte, micros int) (float64, error) {
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	price, err := d.DecryptPriceMicros(cipher)
+	if err != nil {
+		return 0, err
+	}
+	return float64(price) / float64(micros), nil
+}
+
+// DecryptPriceMicros decrypts price without division to micros.
+//
+// Allows to avoid float64 rounding issues.
+func (d *DoubleClick) DecryptPriceMicros(cipher []byte) (uint64, error) {
 	// Increase buffer with +1 payload length and use extra space as a destination array.
 	bufLen := bufPadLen + payloadLenPrice + bufSignLen
 	doubleBufLen := bufLen + payloadLenPrice
@@ -243,9 +395,12 @@ func (d *DoubleClick) DecryptPrice(cipher []byte, micros int) (float64, error) {
 	if err != nil {
 		return 0, err
 	}
+	// Type of the tool may have payload of another length.
+	if len(decrypted) != payloadLenPrice {
+		return 0, ErrBadMsgLen
+	}
 
-	price := binary.BigEndian.Uint64(decrypted)
-	return float64(price) / float64(micros), nil
+	return binary.BigEndian.Uint64(decrypted), nil
 }
 
 // Common decryption helper.
@@ -262,29 +417,24 @@ func (d *DoubleClick) decrypt(dst, cipher []byte, payloadLen int, convFn ConvFn)
 		d.buf = append(d.buf, make([]byte, bufLen-len(d.buf))...)
 	}
 
-	// Compute pad.
-	pad := d.buf[bufPadOffset:bufPadLen]
-	d.hmacE.Reset()
-	d.hmacE.Write(initVector)
-	pad = d.hmacE.Sum(pad[:0])
-
 	// Apply xor to reverse encryption.
 	payload := d.buf[bufPayloadOffset : bufPayloadOffset+payloadLen]
-	for i := 0; i < payloadLen; i++ {
-		payload[i] = cipherText[i] ^ pad[i]
-	}
+	d.xor(payload, cipherText, initVector)
 
 	// Compute signature.
-	bufSignOffset := bufPayloadOffset + payloadLen
-	computedSign := d.buf[bufSignOffset : bufSignOffset+bufSignLen]
-	d.hmacI.Reset()
-	d.hmacI.Write(payload)
-	d.hmacI.Write(initVector)
-	computedSign = d.hmacI.Sum(computedSign[:0])[:integritySignLen]
-	if !hmac.Equal(computedSign, integritySign) {
+	var sign [sha1Len]byte
+	d.hmacI.sumTo(&sign, payload, initVector)
+	if !hmac.Equal(sign[:integritySignLen], integritySign) {
 		return dst, ErrSignCheckFail
 	}
 
+	// Check decryption policy.
+	if d.policy != nil {
+		if err := d.policy.check(initVector, integritySign); err != nil {
+			return dst, err
+		}
+	}
+
 	// Check and apply convert func
 	if convFn != nil {
 		dst = convFn(dst, payload)
@@ -295,76 +445,96 @@ func (d *DoubleClick) decrypt(dst, cipher []byte, payloadLen int, convFn ConvFn)
 	return dst, nil
 }
 
+// Apply xor of src and pads to dst block by block.
+func (d *DoubleClick) xor(dst, src, initVec []byte) {
+	xorPads(&d.hmacE, dst, src, initVec)
+}
+
+// Apply xor of src and pads computed by encryption key k to dst block by block.
+//
+// Each block has own pad computed over init vector and block counter. Pads are kept on stack, so function is safe
+// for concurrent use.
+func xorPads(k *hmacKey, dst, src, initVec []byte) {
+	var (
+		pad [sha1Len]byte
+		ctr [3]byte
+	)
+	for block, off := 0, 0; off < len(src); block, off = block+1, off+bufPadLen {
+		k.sumTo(&pad, initVec, padCounter(&ctr, block))
+		n := len(src) - off
+		if n > bufPadLen {
+			n = bufPadLen
+		}
+		for i := 0; i < n; i++ {
+			dst[off+i] = src[off+i] ^ pad[i]
+		}
+	}
+}
+
+// Apply xor of plain and pads computed by encryption key e to cipher and compute signature of plain by integrity
+// key i.
+//
+// Pad and signature of one-block payloads don't depend on each other, so they are computed at once.
+func encryptPads(e, i *hmacKey, cipher []byte, sign *[sha1Len]byte, plain, initVec []byte) {
+	if len(plain) > bufPadLen {
+		xorPads(e, cipher, plain, initVec)
+		i.sumTo(sign, plain, initVec)
+		return
+	}
+	var pad [sha1Len]byte
+	sumPair(e, &pad, initVec, nil, i, sign, plain, initVec)
+	for j := 0; j < len(plain); j++ {
+		cipher[j] = plain[j] ^ pad[j]
+	}
+}
+
+// Encode counter of pad block the same way as Google's reference implementation (DoubleClickCrypto) does.
+//
+// The first block has no counter, the next ones use one byte 0x00, 0x01, ..., 0xff and every wrap widens the counter
+// by a leading zero byte: 0x0000, 0x0001, ... Thus, block 257 gets 0x0000 and block 513 gets 0x000000.
+func padCounter(ctr *[3]byte, block int) []byte {
+	if block == 0 {
+		return nil
+	}
+	n := (block-1)/256 + 1
+	for i := 0; i < n-1; i++ {
+		ctr[i] = 0
+	}
+	ctr[n-1] = byte(block - 1)
+	return ctr[:n]
+}
+
 // WebSafeEncode encodes string to web-safe base64.
 //
 // Note that this method will trim base64 paddings.
 func (d *DoubleClick) WebSafeEncode(dst, plain []byte) ([]byte, error) {
-	// Get length of further encoded result.
-	n := base64.StdEncoding.EncodedLen(len(plain))
-	// Prepare buffer.
-	if len(d.buf) < n {
-		d.buf = append(d.buf, make([]byte, n-len(d.buf))...)
-	}
-	// Encode to buffer.
-	base64.StdEncoding.Encode(d.buf, plain)
-	// Get index of base64 padding.
-	p := bytes.Index(d.buf, b64Pad)
-	if p < 0 {
-		return dst, ErrNegativePad
-	}
-	// Fill up destination array with encoded string except paddings.
-	dst = append(dst[:0], d.buf[:p]...)
-	return dst, nil
+	return EncodingWebSafe.Encode(dst[:0], plain), nil
 }
 
 // WebSafeDecode decodes web-safe base64 string.
 //
-// Input string must not contain base64 paddings.
+// Input string may contain base64 paddings.
 func (d *DoubleClick) WebSafeDecode(dst, wsStr []byte) ([]byte, error) {
-	n := len(wsStr)
-	// Prepare buffer with double length of the input string.
-	// The first half will used to restore base64 paddings.
-	// The second half will used as intermediate destination array.
-	if len(d.buf) < n*2 {
-		d.buf = append(d.buf, make([]byte, n*2)...)
-	}
-	// Copy input string to buffer.
-	copy(d.buf, wsStr)
-	// Restore paddings.
-	switch {
-	case n%4 == 2:
-		d.buf[n], d.buf[n+1] = '=', '='
-		n += 2
-	case n%4 == 3:
-		d.buf[n] = '='
-		n++
-	}
-	// Restore + and / symbols.
-	for i := 0; i < n; i++ {
-		switch d.buf[i] {
-		case '-':
-			d.buf[i] = '+'
-		case '_':
-			d.buf[i] = '/'
-		}
-	}
-
-	// Get potential length of result.
-	k := base64.StdEncoding.DecodedLen(n)
-	// Decode restored string to the second half of buffer and get final length of result.
-	c, err := base64.StdEncoding.Decode(d.buf[n:n+k], d.buf[:n])
-	if err != nil {
-		return dst, err
-	}
-	// Fill up destination array with decoded string.
-	dst = append(dst, d.buf[n:n+c]...)
-	return dst, nil
+	return b64DecodeAny(dst, wsStr)
 }
 
-// Reset buffer.
+// Reset buffers.
+//
+// Decoded messages and converted plains are wiped as well, so pooled instances don't keep sensitive data.
 func (d *DoubleClick) Reset() {
-	_ = d.buf[len(d.buf)-1]
 	for i := range d.buf {
 		d.buf[i] = 0
 	}
+	d.wbuf = wipe(d.wbuf)
+	d.warr = [wsArrayLen]byte{}
+	d.pbuf = wipe(d.pbuf)
+}
+
+// Zero whole capacity of b and truncate it.
+func wipe(b []byte) []byte {
+	b = b[:cap(b)]
+	for i := range b {
+		b[i] = 0
+	}
+	return b[:0]
 }
@@ -0,0 +1,152 @@
+package doubleclick
+
+// Encoding is a wire encoding of encrypted messages.
+type Encoding int
+
+const (
+	// EncodingRaw keeps messages as is (default).
+	EncodingRaw Encoding = iota
+	// EncodingWebSafe is a web-safe base64 (RFC 4648 URL-safe alphabet) without paddings.
+	EncodingWebSafe
+	// EncodingWebSafePadded is a web-safe base64 with paddings.
+	EncodingWebSafePadded
+	// EncodingBase64 is a standard base64 with paddings.
+	EncodingBase64
+	// EncodingHex is a lower case hex.
+	EncodingHex
+	// EncodingPercent is a standard base64 with percent-escaped special symbols (+, / and =).
+	EncodingPercent
+)
+
+// String returns name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingRaw:
+		return "raw"
+	case EncodingWebSafe:
+		return "web-safe"
+	case EncodingWebSafePadded:
+		return "web-safe-padded"
+	case EncodingBase64:
+		return "base64"
+	case EncodingHex:
+		return "hex"
+	case EncodingPercent:
+		return "percent"
+	default:
+		return "unknown"
+	}
+}
+
+// Encode appends encoded src to dst.
+func (e Encoding) Encode(dst, src []byte) []byte {
+	switch e {
+	case EncodingWebSafe:
+		return b64Encode(dst, src, b64WebSafe, false)
+	case EncodingWebSafePadded:
+		return b64Encode(dst, src, b64WebSafe, true)
+	case EncodingBase64:
+		return b64Encode(dst, src, b64Std, true)
+	case EncodingHex:
+		return ConvPayloadToHex(dst, src)
+	case EncodingPercent:
+		// Encode to standard base64 and escape special symbols in place from the tail.
+		off := len(dst)
+		dst = b64Encode(dst, src, b64Std, true)
+		var n int
+		for i := off; i < len(dst); i++ {
+			if c := dst[i]; c == '+' || c == '/' || c == '=' {
+				n++
+			}
+		}
+		if n == 0 {
+			return dst
+		}
+		l := len(dst)
+		dst = append(dst, make([]byte, n*2)...)
+		for i, j := l-1, len(dst)-1; i >= off; i-- {
+			c := dst[i]
+			if c == '+' || c == '/' || c == '=' {
+				dst[j], dst[j-1], dst[j-2] = hextableUpper[c&0x0f], hextableUpper[c>>4], '%'
+				j -= 3
+			} else {
+				dst[j] = c
+				j--
+			}
+		}
+		return dst
+	default:
+		return append(dst, src...)
+	}
+}
+
+// Decode appends decoded src to dst.
+func (e Encoding) Decode(dst, src []byte) ([]byte, error) {
+	switch e {
+	case EncodingRaw:
+		return append(dst, src...), nil
+	case EncodingWebSafe, EncodingWebSafePadded, EncodingBase64:
+		return b64DecodeAny(dst, src)
+	case EncodingHex:
+		return hexDecode(dst, src)
+	case EncodingPercent:
+		// Unescape to the tail of dst and decode in place.
+		off := len(dst)
+		var err error
+		if dst, err = percentDecode(dst, src); err != nil {
+			return dst[:off], err
+		}
+		return b64DecodeAny(dst[:off], dst[off:])
+	default:
+		return dst, ErrUnkEncoding
+	}
+}
+
+// Decode hex string and append result to dst.
+func hexDecode(dst, src []byte) ([]byte, error) {
+	if len(src)%2 != 0 {
+		return dst, ErrBadHex
+	}
+	for i := 0; i < len(src); i += 2 {
+		hi, lo := unhex(src[i]), unhex(src[i+1])
+		if hi > 0x0f || lo > 0x0f {
+			return dst, ErrBadHex
+		}
+		dst = append(dst, hi<<4|lo)
+	}
+	return dst, nil
+}
+
+// Decode percent-escaped string and append result to dst.
+func percentDecode(dst, src []byte) ([]byte, error) {
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if c == '%' {
+			if i+2 >= len(src) {
+				return dst, ErrBadPercent
+			}
+			hi, lo := unhex(src[i+1]), unhex(src[i+2])
+			if hi > 0x0f || lo > 0x0f {
+				return dst, ErrBadPercent
+			}
+			c = hi<<4 | lo
+			i += 2
+		}
+		dst = append(dst, c)
+	}
+	return dst, nil
+}
+
+// Get value of hex symbol or 0xff if symbol is invalid.
+func unhex(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	default:
+		return 0xff
+	}
+}
@@ -0,0 +1,92 @@
+package doubleclick
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	stbase := base64.StdEncoding.EncodeToString(encryptedAdID)
+	stages := []struct {
+		enc    Encoding
+		expect string
+	}{
+		{EncodingRaw, string(encryptedAdID)},
+		{EncodingWebSafe, base64.RawURLEncoding.EncodeToString(encryptedAdID)},
+		{EncodingWebSafePadded, base64.URLEncoding.EncodeToString(encryptedAdID)},
+		{EncodingBase64, stbase},
+		{EncodingHex, hex.EncodeToString(encryptedAdID)},
+		{EncodingPercent, url.QueryEscape(stbase)},
+	}
+	for _, st := range stages {
+		t.Run(st.enc.String(), func(t *testing.T) {
+			dst := st.enc.Encode([]byte("prefix"), encryptedAdID)
+			if string(dst[6:]) != st.expect {
+				t.Errorf("encode mismatch: expect %s, got %s", st.expect, dst[6:])
+			}
+			dec, err := st.enc.Decode([]byte("prefix"), dst[6:])
+			if err != nil {
+				t.Error(err)
+			}
+			if !bytes.Equal(dec[6:], encryptedAdID) {
+				t.Error("decode mismatch")
+			}
+
+			d := New(TypeAdID, encryptionKey, integrityKey)
+			d.SetEncoding(st.enc)
+			if dst, err = d.Encrypt(dst[:0], initVector, decryptedAdID); err != nil {
+				t.Error(err)
+			}
+			if string(dst) != st.expect {
+				t.Error("encrypt mismatch")
+			}
+			if dst, err = d.Decrypt(dst[:0], []byte(st.expect)); err != nil {
+				t.Error(err)
+			}
+			if !bytes.Equal(dst, decryptedAdID) {
+				t.Error("decrypt mismatch")
+			}
+		})
+	}
+	t.Run("percent padding", func(t *testing.T) {
+		expect := url.QueryEscape(base64.StdEncoding.EncodeToString(encryptedPrice))
+		if dst := EncodingPercent.Encode(nil, encryptedPrice); string(dst) != expect {
+			t.Errorf("encode mismatch: expect %s, got %s", expect, dst)
+		}
+	})
+	t.Run("malformed", func(t *testing.T) {
+		if _, err := EncodingHex.Decode(nil, []byte("0g")); err != ErrBadHex {
+			t.Error("expected error", ErrBadHex)
+		}
+		if _, err := EncodingPercent.Decode(nil, []byte("ab%2")); err != ErrBadPercent {
+			t.Error("expected error", ErrBadPercent)
+		}
+		if _, err := Encoding(100).Decode(nil, nil); err != ErrUnkEncoding {
+			t.Error("expected error", ErrUnkEncoding)
+		}
+	})
+}
+
+func BenchmarkEncoding(b *testing.B) {
+	for _, enc := range []Encoding{EncodingWebSafe, EncodingHex, EncodingPercent} {
+		b.Run(enc.String(), func(b *testing.B) {
+			d := New(TypePrice, encryptionKey, integrityKey)
+			d.SetEncoding(enc)
+			msg, _ := d.EncryptPrice(decryptedPrice, nil, initVector, micros)
+			b.ResetTimer()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				price, err := d.DecryptPrice(msg, micros)
+				if err != nil {
+					b.Error(err)
+				}
+				if price != decryptedPrice {
+					b.Error("decrypt price failed")
+				}
+			}
+		})
+	}
+}
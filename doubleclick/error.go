@@ -1,6 +1,9 @@
 package doubleclick
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
 
 var (
 	ErrUnkType       = errors.New("unknown type")
@@ -8,5 +11,47 @@ var (
 	ErrBadMsgLen     = errors.New("unsupported message length")
 	ErrBadPlainLen   = errors.New("unsupported plain source length")
 	ErrSignCheckFail = errors.New("signature check failed")
+	// Deprecated: web-safe encoding doesn't fail on inputs without paddings anymore.
 	ErrNegativePad   = errors.New("negative base64 pad index")
+	ErrNoKeys        = errors.New("no keys provided")
+	ErrNoKeyForTime  = errors.New("no keys valid at init vector time")
+	ErrExpired       = errors.New("message expired")
+	ErrFromFuture    = errors.New("message from the future")
+	ErrReplay        = errors.New("message replay detected")
+	ErrBadHyperlocal = errors.New("malformed hyperlocal payload")
+	ErrBadScale      = errors.New("unsupported decimal scale")
+	ErrBadPrice      = errors.New("price must be non-negative finite number")
+	ErrBadMicros     = errors.New("micros must be positive")
+	ErrPriceOverflow = errors.New("price in micros overflows uint64")
+	ErrBadBase64     = errors.New("malformed base64 string")
+	ErrAmbiguousType = errors.New("ambiguous message type")
+	ErrUnkMsgType    = errors.New("unable to detect message type")
+	ErrTypeExists    = errors.New("type already registered")
+	ErrUnkEncoding   = errors.New("unknown encoding")
+	ErrBadHex        = errors.New("malformed hex string")
+	ErrBadPercent    = errors.New("malformed percent-escaped string")
+	ErrBadBatchLen   = errors.New("destination slices are shorter than batch")
+	ErrUnkTenant     = errors.New("unknown tenant")
+	ErrEmptyKey      = errors.New("empty key")
+	ErrBadKeyFormat  = errors.New("unknown key format, expected base64, hex or raw bytes")
+	ErrBadKeyLen     = errors.New("key must be 32 bytes long")
+	ErrZeroKey       = errors.New("key consists of zeros")
+	ErrSameKeys      = errors.New("encryption and integrity keys are identical")
+	ErrBadYAML       = errors.New("malformed YAML")
+	ErrBadKeyFile    = errors.New("unsupported key file format")
 )
+
+// TenantError reports unknown tenant ID.
+//
+// Matches ErrUnkTenant using errors.Is.
+type TenantError struct {
+	ID string
+}
+
+func (e *TenantError) Error() string {
+	return ErrUnkTenant.Error() + " " + strconv.Quote(e.ID)
+}
+
+func (e *TenantError) Is(target error) bool {
+	return target == ErrUnkTenant
+}
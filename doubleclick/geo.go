@@ -0,0 +1,173 @@
+package doubleclick
+
+import "math"
+
+const (
+	// Mean Earth radius in meters.
+	earthRadius = 6371008.8
+	// Geohash alphabet.
+	geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+	// Max supported geohash precision.
+	geohashMaxPrecision = 12
+)
+
+// BBox is a bounding box of the polygon.
+type BBox struct {
+	Min, Max Point
+}
+
+// Contains checks if point lies inside the bounding box (including borders).
+func (b BBox) Contains(p Point) bool {
+	return p.Latitude >= b.Min.Latitude && p.Latitude <= b.Max.Latitude &&
+		p.Longitude >= b.Min.Longitude && p.Longitude <= b.Max.Longitude
+}
+
+// Contains checks if point lies inside the polygon.
+//
+// Uses ray casting algorithm on planar coordinates, that is accurate enough for hyperlocal-sized polygons.
+func (l *Hyperlocal) Contains(p Point) bool {
+	c := l.Corners
+	if len(c) < 3 {
+		return false
+	}
+	var (
+		in   bool
+		x, y = float64(p.Longitude), float64(p.Latitude)
+	)
+	for i, j := 0, len(c)-1; i < len(c); j, i = i, i+1 {
+		xi, yi := float64(c[i].Longitude), float64(c[i].Latitude)
+		xj, yj := float64(c[j].Longitude), float64(c[j].Latitude)
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			in = !in
+		}
+	}
+	return in
+}
+
+// BBox returns bounding box of the polygon.
+func (l *Hyperlocal) BBox() BBox {
+	c := l.Corners
+	if len(c) == 0 {
+		return BBox{}
+	}
+	b := BBox{Min: c[0], Max: c[0]}
+	for i := 1; i < len(c); i++ {
+		b.Min.Latitude = min32(b.Min.Latitude, c[i].Latitude)
+		b.Min.Longitude = min32(b.Min.Longitude, c[i].Longitude)
+		b.Max.Latitude = max32(b.Max.Latitude, c[i].Latitude)
+		b.Max.Longitude = max32(b.Max.Longitude, c[i].Longitude)
+	}
+	return b
+}
+
+// Centroid returns centroid of the polygon.
+//
+// Degenerate polygons (with zero area) fall back to the mean of corners.
+func (l *Hyperlocal) Centroid() Point {
+	c := l.Corners
+	if len(c) == 0 {
+		return Point{}
+	}
+	var a, cx, cy, mx, my float64
+	for i, j := 0, len(c)-1; i < len(c); j, i = i, i+1 {
+		xi, yi := float64(c[i].Longitude), float64(c[i].Latitude)
+		xj, yj := float64(c[j].Longitude), float64(c[j].Latitude)
+		f := xj*yi - xi*yj
+		a += f
+		cx += (xj + xi) * f
+		cy += (yj + yi) * f
+		mx += xi
+		my += yi
+	}
+	if math.Abs(a) < 1e-12 {
+		n := float64(len(c))
+		return Point{Latitude: float32(my / n), Longitude: float32(mx / n)}
+	}
+	a *= 3
+	return Point{Latitude: float32(cy / a), Longitude: float32(cx / a)}
+}
+
+// Contains checks if point lies inside any polygon of the set.
+func (h *HyperlocalSet) Contains(p Point) bool {
+	for i := 0; i < len(h.Hyperlocal); i++ {
+		if h.Hyperlocal[i].Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// DistanceToCenter returns distance in meters between point and center point of the set.
+func (h *HyperlocalSet) DistanceToCenter(p Point) float64 {
+	return Distance(h.CenterPoint, p)
+}
+
+// Distance returns great-circle distance in meters between two points using haversine formula.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := rad(a.Latitude), rad(b.Latitude)
+	dlat, dlon := lat2-lat1, rad(b.Longitude)-rad(a.Longitude)
+	s1, s2 := math.Sin(dlat/2), math.Sin(dlon/2)
+	x := s1*s1 + math.Cos(lat1)*math.Cos(lat2)*s2*s2
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(1, x)))
+}
+
+// AppendGeohash appends geohash of the point with given precision (1..12 symbols) to dst.
+func (p Point) AppendGeohash(dst []byte, precision int) []byte {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > geohashMaxPrecision {
+		precision = geohashMaxPrecision
+	}
+	var (
+		lat, lon     = float64(p.Latitude), float64(p.Longitude)
+		latLo, latHi = -90.0, 90.0
+		lonLo, lonHi = -180.0, 180.0
+		even         = true
+		bit, ch      int
+	)
+	for precision > 0 {
+		if even {
+			if mid := (lonLo + lonHi) / 2; lon >= mid {
+				ch = ch<<1 | 1
+				lonLo = mid
+			} else {
+				ch <<= 1
+				lonHi = mid
+			}
+		} else {
+			if mid := (latLo + latHi) / 2; lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			dst = append(dst, geohashBase32[ch])
+			bit, ch = 0, 0
+			precision--
+		}
+	}
+	return dst
+}
+
+func rad(deg float32) float64 {
+	return float64(deg) * math.Pi / 180
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,84 @@
+package doubleclick
+
+import (
+	"math"
+	"testing"
+)
+
+var hyperlocalSquare = Hyperlocal{Corners: []Point{
+	{Latitude: 40.7, Longitude: -74.01},
+	{Latitude: 40.7, Longitude: -73.99},
+	{Latitude: 40.72, Longitude: -73.99},
+	{Latitude: 40.72, Longitude: -74.01},
+}}
+
+func TestGeo(t *testing.T) {
+	t.Run("contains", func(t *testing.T) {
+		if !hyperlocalSquare.Contains(Point{Latitude: 40.71, Longitude: -74}) {
+			t.Error("point must be inside")
+		}
+		if hyperlocalSquare.Contains(Point{Latitude: 40.73, Longitude: -74}) {
+			t.Error("point must be outside")
+		}
+		h := HyperlocalSet{Hyperlocal: []Hyperlocal{hyperlocalSquare}}
+		if !h.Contains(Point{Latitude: 40.705, Longitude: -74.005}) {
+			t.Error("point must be inside")
+		}
+	})
+	t.Run("bbox", func(t *testing.T) {
+		b := hyperlocalSquare.BBox()
+		if b.Min != (Point{Latitude: 40.7, Longitude: -74.01}) || b.Max != (Point{Latitude: 40.72, Longitude: -73.99}) {
+			t.Error("bbox mismatch", b)
+		}
+		if !b.Contains(Point{Latitude: 40.71, Longitude: -74}) {
+			t.Error("point must be inside")
+		}
+	})
+	t.Run("centroid", func(t *testing.T) {
+		c := hyperlocalSquare.Centroid()
+		if math.Abs(float64(c.Latitude)-40.71) > 1e-4 || math.Abs(float64(c.Longitude)+74) > 1e-4 {
+			t.Error("centroid mismatch", c)
+		}
+		line := Hyperlocal{Corners: []Point{{Latitude: 1, Longitude: 1}, {Latitude: 3, Longitude: 3}}}
+		if c = line.Centroid(); c != (Point{Latitude: 2, Longitude: 2}) {
+			t.Error("degenerate centroid mismatch", c)
+		}
+	})
+	t.Run("distance", func(t *testing.T) {
+		// Distance of one degree of meridian.
+		d := Distance(Point{Latitude: 0, Longitude: 0}, Point{Latitude: 1, Longitude: 0})
+		if math.Abs(d-111195) > 1 {
+			t.Error("distance mismatch", d)
+		}
+		h := HyperlocalSet{CenterPoint: Point{Latitude: 45, Longitude: 45}}
+		if d = h.DistanceToCenter(h.CenterPoint); d != 0 {
+			t.Error("distance mismatch", d)
+		}
+	})
+	t.Run("geohash", func(t *testing.T) {
+		p := Point{Latitude: 57.64911, Longitude: 10.40744}
+		if s := string(p.AppendGeohash(nil, 8)); s != "u4pruydq" {
+			t.Error("geohash mismatch", s)
+		}
+	})
+}
+
+func BenchmarkGeo(b *testing.B) {
+	b.Run("contains", func(b *testing.B) {
+		p := Point{Latitude: 40.71, Longitude: -74}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if !hyperlocalSquare.Contains(p) {
+				b.Error("point must be inside")
+			}
+		}
+	})
+	b.Run("geohash", func(b *testing.B) {
+		p := Point{Latitude: 57.64911, Longitude: 10.40744}
+		var dst []byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst = p.AppendGeohash(dst[:0], 9)
+		}
+	})
+}
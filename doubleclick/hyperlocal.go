@@ -0,0 +1,324 @@
+package doubleclick
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+const (
+	// Protobuf wire types.
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+	// HyperlocalSet fields.
+	fieldHyperlocal  = 1
+	fieldCenterPoint = 2
+	// Hyperlocal fields.
+	fieldCorners = 1
+	// Point fields.
+	fieldLatitude  = 1
+	fieldLongitude = 2
+)
+
+// Point is a geographical point.
+type Point struct {
+	Latitude, Longitude float32
+}
+
+// Hyperlocal is a polygon described by corner points.
+type Hyperlocal struct {
+	Corners []Point
+}
+
+// HyperlocalSet is a decrypted hyperlocal targeting signal.
+//
+// See https://developers.google.com/authorized-buyers/rtb/response-guide/decrypt-hyperlocal for details.
+type HyperlocalSet struct {
+	Hyperlocal  []Hyperlocal
+	CenterPoint Point
+}
+
+// Unmarshal decodes protobuf encoded payload.
+//
+// Set reuses previously allocated space, so decoding to the same set doesn't allocate after warm-up.
+func (h *HyperlocalSet) Unmarshal(p []byte) error {
+	h.Reset()
+	for len(p) > 0 {
+		field, val, rest, err := pbNext(p)
+		if err != nil {
+			return err
+		}
+		p = rest
+		switch field {
+		case fieldHyperlocal:
+			if val == nil {
+				return ErrBadHyperlocal
+			}
+			if len(h.Hyperlocal) < cap(h.Hyperlocal) {
+				h.Hyperlocal = h.Hyperlocal[:len(h.Hyperlocal)+1]
+			} else {
+				h.Hyperlocal = append(h.Hyperlocal, Hyperlocal{})
+			}
+			if err = h.Hyperlocal[len(h.Hyperlocal)-1].unmarshal(val); err != nil {
+				return err
+			}
+		case fieldCenterPoint:
+			if val == nil {
+				return ErrBadHyperlocal
+			}
+			if err = h.CenterPoint.unmarshal(val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Marshal appends protobuf encoded set to dst.
+func (h *HyperlocalSet) Marshal(dst []byte) []byte {
+	for i := 0; i < len(h.Hyperlocal); i++ {
+		dst = pbAppendTag(dst, fieldHyperlocal, wireBytes)
+		dst = pbAppendVarint(dst, uint64(h.Hyperlocal[i].size()))
+		dst = h.Hyperlocal[i].marshal(dst)
+	}
+	dst = pbAppendTag(dst, fieldCenterPoint, wireBytes)
+	dst = pbAppendVarint(dst, uint64(h.CenterPoint.size()))
+	return h.CenterPoint.marshal(dst)
+}
+
+// AppendJSON appends JSON representation of the set to dst.
+func (h *HyperlocalSet) AppendJSON(dst []byte) []byte {
+	dst = append(dst, `{"hyperlocal":[`...)
+	for i := 0; i < len(h.Hyperlocal); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `{"corners":[`...)
+		corners := h.Hyperlocal[i].Corners
+		for j := 0; j < len(corners); j++ {
+			if j > 0 {
+				dst = append(dst, ',')
+			}
+			dst = corners[j].appendJSON(dst)
+		}
+		dst = append(dst, "]}"...)
+	}
+	dst = append(dst, `],"center_point":`...)
+	dst = h.CenterPoint.appendJSON(dst)
+	return append(dst, '}')
+}
+
+// Reset set with keeping allocated space.
+func (h *HyperlocalSet) Reset() {
+	for i := 0; i < len(h.Hyperlocal); i++ {
+		h.Hyperlocal[i].Corners = h.Hyperlocal[i].Corners[:0]
+	}
+	h.Hyperlocal = h.Hyperlocal[:0]
+	h.CenterPoint = Point{}
+}
+
+func (l *Hyperlocal) unmarshal(p []byte) error {
+	for len(p) > 0 {
+		field, val, rest, err := pbNext(p)
+		if err != nil {
+			return err
+		}
+		p = rest
+		if field == fieldCorners {
+			if val == nil {
+				return ErrBadHyperlocal
+			}
+			var pt Point
+			if err = pt.unmarshal(val); err != nil {
+				return err
+			}
+			l.Corners = append(l.Corners, pt)
+		}
+	}
+	return nil
+}
+
+func (l *Hyperlocal) size() (n int) {
+	for i := 0; i < len(l.Corners); i++ {
+		n += 2 + l.Corners[i].size()
+	}
+	return
+}
+
+func (l *Hyperlocal) marshal(dst []byte) []byte {
+	for i := 0; i < len(l.Corners); i++ {
+		dst = pbAppendTag(dst, fieldCorners, wireBytes)
+		dst = pbAppendVarint(dst, uint64(l.Corners[i].size()))
+		dst = l.Corners[i].marshal(dst)
+	}
+	return dst
+}
+
+func (p *Point) unmarshal(b []byte) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrBadHyperlocal
+		}
+		field, wire := tag>>3, tag&7
+		if wire == wireFixed32 && (field == fieldLatitude || field == fieldLongitude) {
+			if len(b) < n+4 {
+				return ErrBadHyperlocal
+			}
+			v := math.Float32frombits(binary.LittleEndian.Uint32(b[n:]))
+			if field == fieldLatitude {
+				p.Latitude = v
+			} else {
+				p.Longitude = v
+			}
+			b = b[n+4:]
+			continue
+		}
+		_, _, rest, err := pbNext(b)
+		if err != nil {
+			return err
+		}
+		b = rest
+	}
+	return nil
+}
+
+func (p *Point) size() int {
+	// Two fixed32 fields with one byte tags.
+	return 10
+}
+
+func (p *Point) marshal(dst []byte) []byte {
+	dst = pbAppendTag(dst, fieldLatitude, wireFixed32)
+	dst = pbAppendFixed32(dst, math.Float32bits(p.Latitude))
+	dst = pbAppendTag(dst, fieldLongitude, wireFixed32)
+	return pbAppendFixed32(dst, math.Float32bits(p.Longitude))
+}
+
+func (p *Point) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"latitude":`...)
+	dst = strconv.AppendFloat(dst, float64(p.Latitude), 'g', -1, 32)
+	dst = append(dst, `,"longitude":`...)
+	dst = strconv.AppendFloat(dst, float64(p.Longitude), 'g', -1, 32)
+	return append(dst, '}')
+}
+
+// ConvPayloadToHyperlocalJSON converts hyperlocal payload to JSON.
+//
+// Malformed payload produces empty JSON object.
+func ConvPayloadToHyperlocalJSON(dst, src []byte) []byte {
+	var h HyperlocalSet
+	if err := h.Unmarshal(src); err != nil {
+		return append(dst, "{}"...)
+	}
+	return h.AppendJSON(dst)
+}
+
+// DecryptHyperlocal decrypts cipher and decodes payload to dst set.
+//
+// Fixed hyperlocal type holds only center point, use TypeRaw to decrypt set of any size.
+func (d *DoubleClick) DecryptHyperlocal(dst *HyperlocalSet, cipher []byte) error {
+	// Increase buffer with +1 payload length and use extra space as an intermediate array.
+	bufLen := bufPadLen + len(cipher) + bufSignLen
+	doubleBufLen := bufLen + len(cipher)
+	if len(d.buf) < doubleBufLen {
+		d.buf = append(d.buf, make([]byte, doubleBufLen-len(d.buf))...)
+	}
+	payload, err := d.DecryptFn(d.buf[bufLen:bufLen], cipher, nil)
+	if err != nil {
+		return err
+	}
+	// Payload converted by default convert func of the type isn't a protobuf set.
+	if len(payload) != len(cipher)-msgOverhead {
+		return ErrBadMsgLen
+	}
+	return dst.Unmarshal(payload)
+}
+
+// EncryptHyperlocal encodes and encrypts src set to dst using initVec.
+func (d *DoubleClick) EncryptHyperlocal(dst []byte, initVec []byte, src *HyperlocalSet) ([]byte, error) {
+	// Increase buffer with +1 payload length and use extra space as an intermediate source array.
+	plainLen := src.size()
+	bufLen := bufPadLen + plainLen + bufSignLen
+	doubleBufLen := bufLen + plainLen
+	if len(d.buf) < doubleBufLen {
+		d.buf = append(d.buf, make([]byte, doubleBufLen-len(d.buf))...)
+	}
+	plain := src.Marshal(d.buf[bufLen:bufLen])
+	return d.Encrypt(dst, initVec, plain)
+}
+
+func (h *HyperlocalSet) size() (n int) {
+	for i := 0; i < len(h.Hyperlocal); i++ {
+		k := h.Hyperlocal[i].size()
+		n += 1 + pbVarintLen(uint64(k)) + k
+	}
+	return n + 2 + h.CenterPoint.size()
+}
+
+// Read next field of protobuf message.
+//
+// Returns field number, value (only for length-delimited fields) and the rest of message.
+func pbNext(p []byte) (field uint64, val, rest []byte, err error) {
+	tag, n := binary.Uvarint(p)
+	if n <= 0 {
+		err = ErrBadHyperlocal
+		return
+	}
+	field, p = tag>>3, p[n:]
+	switch tag & 7 {
+	case wireVarint:
+		if _, n = binary.Uvarint(p); n <= 0 {
+			err = ErrBadHyperlocal
+			return
+		}
+		rest = p[n:]
+	case wireFixed64:
+		if len(p) < 8 {
+			err = ErrBadHyperlocal
+			return
+		}
+		rest = p[8:]
+	case wireBytes:
+		var l uint64
+		if l, n = binary.Uvarint(p); n <= 0 || uint64(len(p)-n) < l {
+			err = ErrBadHyperlocal
+			return
+		}
+		val, rest = p[n:n+int(l)], p[n+int(l):]
+	case wireFixed32:
+		if len(p) < 4 {
+			err = ErrBadHyperlocal
+			return
+		}
+		rest = p[4:]
+	default:
+		err = ErrBadHyperlocal
+	}
+	return
+}
+
+func pbAppendTag(dst []byte, field, wire uint64) []byte {
+	return pbAppendVarint(dst, field<<3|wire)
+}
+
+func pbAppendVarint(dst []byte, v uint64) []byte {
+	for v >= 0x80 {
+		dst = append(dst, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(dst, byte(v))
+}
+
+func pbAppendFixed32(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func pbVarintLen(v uint64) (n int) {
+	for n = 1; v >= 0x80; n++ {
+		v >>= 7
+	}
+	return
+}
@@ -44,6 +44,105 @@ func TestHyperlocal(t *testing.T) {
 			t.Error("encrypt hyperlocal failed")
 		}
 	})
+	t.Run("converted payload", func(t *testing.T) {
+		d := New(registerTestType(t), encryptionKey, integrityKey)
+		msg, err := d.Encrypt(nil, initVector, []byte("000102030405060708090a0b0c0d0e0f1011121314151617"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var set HyperlocalSet
+		if err = d.DecryptHyperlocal(&set, msg); err != ErrBadMsgLen {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+	})
+}
+
+func TestHyperlocalSet(t *testing.T) {
+	set := HyperlocalSet{
+		Hyperlocal: []Hyperlocal{{Corners: []Point{
+			{Latitude: 40.7, Longitude: -74.01},
+			{Latitude: 40.7, Longitude: -73.99},
+			{Latitude: 40.72, Longitude: -73.99},
+			{Latitude: 40.72, Longitude: -74.01},
+		}}},
+		CenterPoint: Point{Latitude: 40.71, Longitude: -74},
+	}
+	t.Run("unmarshal", func(t *testing.T) {
+		var h HyperlocalSet
+		if err := h.Unmarshal(decryptedHyperlocal); err != nil {
+			t.Error(err)
+		}
+		if len(h.Hyperlocal) != 0 || h.CenterPoint != (Point{Latitude: 45, Longitude: 45}) {
+			t.Error("unmarshal hyperlocal failed")
+		}
+	})
+	t.Run("marshal", func(t *testing.T) {
+		h := HyperlocalSet{CenterPoint: Point{Latitude: 45, Longitude: 45}}
+		if !bytes.Equal(h.Marshal(nil), decryptedHyperlocal) {
+			t.Error("marshal hyperlocal failed")
+		}
+	})
+	t.Run("round trip", func(t *testing.T) {
+		var h HyperlocalSet
+		if err := h.Unmarshal(set.Marshal(nil)); err != nil {
+			t.Error(err)
+		}
+		if len(h.Hyperlocal) != 1 || len(h.Hyperlocal[0].Corners) != 4 || h.CenterPoint != set.CenterPoint {
+			t.Fatal("round trip hyperlocal failed")
+		}
+		for i, p := range h.Hyperlocal[0].Corners {
+			if p != set.Hyperlocal[0].Corners[i] {
+				t.Error("corner mismatch", i)
+			}
+		}
+	})
+	t.Run("malformed", func(t *testing.T) {
+		var h HyperlocalSet
+		if err := h.Unmarshal(decryptedHyperlocal[:5]); err != ErrBadHyperlocal {
+			t.Error("expected error", ErrBadHyperlocal)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		dst := ConvPayloadToHyperlocalJSON(nil, decryptedHyperlocal)
+		if string(dst) != `{"hyperlocal":[],"center_point":{"latitude":45,"longitude":45}}` {
+			t.Error("convert hyperlocal to JSON failed, got", string(dst))
+		}
+	})
+	t.Run("decrypt", func(t *testing.T) {
+		d := New(TypeHyperlocal, encryptionKey, integrityKey)
+		var h HyperlocalSet
+		if err := d.DecryptHyperlocal(&h, encryptedHyperlocal); err != nil {
+			t.Error(err)
+		}
+		if h.CenterPoint != (Point{Latitude: 45, Longitude: 45}) {
+			t.Error("decrypt hyperlocal set failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		d := New(TypeHyperlocal, encryptionKey, integrityKey)
+		h := HyperlocalSet{CenterPoint: Point{Latitude: 45, Longitude: 45}}
+		dst, err := d.EncryptHyperlocal(nil, initVector, &h)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedHyperlocal) {
+			t.Error("encrypt hyperlocal set failed")
+		}
+	})
+	t.Run("raw", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		enc, err := d.EncryptHyperlocal(nil, initVector, &set)
+		if err != nil {
+			t.Error(err)
+		}
+		var h HyperlocalSet
+		if err = d.DecryptHyperlocal(&h, enc); err != nil {
+			t.Error(err)
+		}
+		if len(h.Hyperlocal) != 1 || len(h.Hyperlocal[0].Corners) != 4 || h.CenterPoint != set.CenterPoint {
+			t.Error("raw hyperlocal set failed")
+		}
+	})
 }
 
 func BenchmarkHyperlocal(b *testing.B) {
@@ -89,6 +188,21 @@ func BenchmarkHyperlocal(b *testing.B) {
 	})
 }
 
+func BenchmarkHyperlocalSet(b *testing.B) {
+	b.Run("decrypt", func(b *testing.B) {
+		d := New(TypeHyperlocal, encryptionKey, integrityKey)
+		var h HyperlocalSet
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := d.DecryptHyperlocal(&h, encryptedHyperlocal); err != nil {
+				b.Error(err)
+			}
+			d.Reset()
+		}
+	})
+}
+
 func BenchmarkHyperlocalParallel(b *testing.B) {
 	decFn := func(b *testing.B, n int) {
 		b.ResetTimer()
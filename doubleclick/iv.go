@@ -0,0 +1,98 @@
+package doubleclick
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Init vector bounds (AdX convention).
+	initVectorSecOffset      = 0
+	initVectorUsecOffset     = 4
+	initVectorServerIDOffset = 8
+	initVectorServerIDLen    = 8
+)
+
+// InitVectorGen is a generator of init vectors following AdX convention:
+// initVector = seconds:4 || micros:4 || serverId:8
+//
+// Generator guarantees that issued timestamps strictly increase, thus init vectors never repeat within one generator.
+// Generator is thread-safe.
+type InitVectorGen struct {
+	serverID [initVectorServerIDLen]byte
+	// Last issued timestamp in microseconds.
+	last uint64
+	now  func() time.Time
+}
+
+var (
+	defInitVectorGen    *InitVectorGen
+	defInitVectorGenErr error
+	defInitVectorGenOnc sync.Once
+)
+
+// NewInitVectorGen makes new generator with given server ID.
+func NewInitVectorGen(serverID uint64) *InitVectorGen {
+	g := &InitVectorGen{now: time.Now}
+	binary.BigEndian.PutUint64(g.serverID[:], serverID)
+	return g
+}
+
+// NewRandInitVectorGen makes new generator with random server ID taken from crypto/rand.
+func NewRandInitVectorGen() (*InitVectorGen, error) {
+	g := &InitVectorGen{now: time.Now}
+	if _, err := rand.Read(g.serverID[:]); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// SetClock replaces time source of the generator.
+//
+// Must be called before first use of the generator.
+func (g *InitVectorGen) SetClock(now func() time.Time) {
+	g.now = now
+}
+
+// ServerID returns server ID of the generator.
+func (g *InitVectorGen) ServerID() uint64 {
+	return binary.BigEndian.Uint64(g.serverID[:])
+}
+
+// Generate appends new init vector to dst.
+func (g *InitVectorGen) Generate(dst []byte) []byte {
+	ts := uint64(g.now().UnixNano() / int64(time.Microsecond))
+	for {
+		last := atomic.LoadUint64(&g.last)
+		if ts <= last {
+			ts = last + 1
+		}
+		if atomic.CompareAndSwapUint64(&g.last, last, ts) {
+			break
+		}
+	}
+	var buf [initVectorLen]byte
+	binary.BigEndian.PutUint32(buf[initVectorSecOffset:], uint32(ts/1e6))
+	binary.BigEndian.PutUint32(buf[initVectorUsecOffset:], uint32(ts%1e6))
+	copy(buf[initVectorServerIDOffset:], g.serverID[:])
+	return append(dst, buf[:]...)
+}
+
+// Get default generator with random server ID.
+func defaultInitVectorGen() (*InitVectorGen, error) {
+	defInitVectorGenOnc.Do(func() {
+		defInitVectorGen, defInitVectorGenErr = NewRandInitVectorGen()
+	})
+	return defInitVectorGen, defInitVectorGenErr
+}
+
+// Get timestamp encoded to the init vector.
+func initVectorTime(initVec []byte) time.Time {
+	_ = initVec[initVectorLen-1]
+	sec := binary.BigEndian.Uint32(initVec[initVectorSecOffset:initVectorUsecOffset])
+	usec := binary.BigEndian.Uint32(initVec[initVectorUsecOffset:initVectorServerIDOffset])
+	return time.Unix(int64(sec), int64(usec)*int64(time.Microsecond))
+}
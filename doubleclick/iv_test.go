@@ -0,0 +1,125 @@
+package doubleclick
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+var initVectorTs = time.Date(2000, 1, 1, 17, 34, 56, 789000000, time.UTC)
+
+func TestInitVectorGen(t *testing.T) {
+	t.Run("generate", func(t *testing.T) {
+		g := NewInitVectorGen(0x0123456789abcdef)
+		g.SetClock(func() time.Time { return initVectorTs })
+		iv := g.Generate(nil)
+		if !bytes.Equal(iv, initVector) {
+			t.Error("init vector mismatch")
+		}
+		iv = g.Generate(iv[:0])
+		if !initVectorTime(iv).Equal(initVectorTs.Add(time.Microsecond)) {
+			t.Error("init vector must be unique")
+		}
+	})
+	t.Run("random server ID", func(t *testing.T) {
+		g0, err := NewRandInitVectorGen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		g1, err := NewRandInitVectorGen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g0.ServerID() == g1.ServerID() {
+			t.Error("server IDs must differ")
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		g := NewInitVectorGen(1)
+		g.SetClock(func() time.Time { return initVectorTs })
+		var (
+			wg  sync.WaitGroup
+			mux sync.Mutex
+			set = make(map[string]struct{})
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var iv []byte
+				for j := 0; j < 1000; j++ {
+					iv = g.Generate(iv[:0])
+					mux.Lock()
+					set[string(iv)] = struct{}{}
+					mux.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if len(set) != 8000 {
+			t.Error("init vectors must be unique, got", len(set))
+		}
+	})
+	t.Run("encrypt auto", func(t *testing.T) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		g := NewInitVectorGen(0x0123456789abcdef)
+		g.SetClock(func() time.Time { return initVectorTs })
+		d.SetInitVectorGen(g)
+		dst, err := d.EncryptAuto(nil, decryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedAdID) {
+			t.Error("encrypt auto AdID failed")
+		}
+	})
+	t.Run("encrypt price auto", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		dst, err := d.EncryptPriceAuto(decryptedPrice, nil, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		dst1, err := d.EncryptPriceAuto(decryptedPrice, nil, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if bytes.Equal(dst[:initVectorLen], dst1[:initVectorLen]) {
+			t.Error("init vectors must differ")
+		}
+		price, err := d.DecryptPrice(dst1, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+	})
+}
+
+func BenchmarkInitVectorGen(b *testing.B) {
+	b.Run("generate", func(b *testing.B) {
+		g := NewInitVectorGen(1)
+		var iv []byte
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			iv = g.Generate(iv[:0])
+		}
+	})
+	b.Run("encrypt price auto", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if dst, err = d.EncryptPriceAuto(decryptedPrice, dst[:0], micros); err != nil {
+				b.Error(err)
+			}
+			d.Reset()
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package doubleclick
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// KeyPair is a pair of encryption and integrity keys identified by ID.
+type KeyPair struct {
+	// ID is an arbitrary key pair identifier, e.g. name or version of the keys.
+	ID string
+	// Encryption and integrity keys.
+	EncryptionKey, IntegrityKey []byte
+}
+
+// KeyLen is a length of AdX encryption and integrity keys.
+const KeyLen = 32
+
+// KeyError describes invalid key material.
+type KeyError struct {
+	// Key is a name of invalid key, e.g. "encryption" or "integrity".
+	Key string
+	// Len is a length of decoded key.
+	Len int
+	// Err is an underlying error, e.g. ErrBadKeyLen.
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	msg := e.Err.Error()
+	if e.Err == ErrBadKeyLen {
+		msg += " (got " + strconv.Itoa(e.Len) + ")"
+	}
+	if len(e.Key) > 0 {
+		msg = e.Key + " key: " + msg
+	}
+	return msg
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// ParseKey decodes key from one of the formats:
+// * web-safe base64 with or without paddings (format of keys issued by Google)
+// * standard base64
+// * hex (with optional "0x" prefix)
+// * raw bytes of KeyLen length
+//
+// Surrounding whitespaces are ignored. Decoded key is appended to dst.
+//
+// Well-formed hex or base64 key of wrong length is reported as KeyError with ErrBadKeyLen, so raw bytes are accepted
+// only if key isn't a valid hex or base64 string.
+func ParseKey(dst []byte, key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if len(key) == 0 {
+		return dst, ErrEmptyKey
+	}
+	// Decoded length of well-formed key of wrong length.
+	n := -1
+	// Hex.
+	if r, err := hexDecode(dst, []byte(strings.TrimPrefix(key, "0x"))); err == nil {
+		if n = len(r) - len(dst); n == KeyLen {
+			return r, nil
+		}
+	}
+	// Base64 (any alphabet, with or without paddings).
+	if b := []byte(key); isBase64(b) {
+		if r, err := b64DecodeAny(dst, b); err == nil {
+			if l := len(r) - len(dst); l == KeyLen {
+				return r, nil
+			} else if n < 0 {
+				n = l
+			}
+		}
+	}
+	if n >= 0 {
+		return dst, &KeyError{Len: n, Err: ErrBadKeyLen}
+	}
+	// Raw bytes.
+	if len(key) == KeyLen {
+		return append(dst, key...), nil
+	}
+	return dst, ErrBadKeyFormat
+}
+
+// ParseKeyPair parses and validates encryption and integrity keys (see ParseKey and Validate).
+func ParseKeyPair(id, encryptionKey, integrityKey string) (KeyPair, error) {
+	kp := KeyPair{ID: id}
+	var err error
+	if kp.EncryptionKey, err = ParseKey(nil, encryptionKey); err != nil {
+		return kp, keyError("encryption", err)
+	}
+	if kp.IntegrityKey, err = ParseKey(nil, integrityKey); err != nil {
+		return kp, keyError("integrity", err)
+	}
+	return kp, kp.Validate()
+}
+
+// Attach key name to error of ParseKey.
+func keyError(key string, err error) error {
+	if e, ok := err.(*KeyError); ok {
+		e.Key = key
+		return e
+	}
+	return &KeyError{Key: key, Err: err}
+}
+
+// Validate checks key pair for obviously broken material.
+//
+// Both keys must be KeyLen long, must not consist of zeros and must differ from each other.
+func (kp KeyPair) Validate() error {
+	if err := validateKey(kp.EncryptionKey); err != nil {
+		return &KeyError{Key: "encryption", Len: len(kp.EncryptionKey), Err: err}
+	}
+	if err := validateKey(kp.IntegrityKey); err != nil {
+		return &KeyError{Key: "integrity", Len: len(kp.IntegrityKey), Err: err}
+	}
+	if bytes.Equal(kp.EncryptionKey, kp.IntegrityKey) {
+		return &KeyError{Key: "integrity", Len: len(kp.IntegrityKey), Err: ErrSameKeys}
+	}
+	return nil
+}
+
+func validateKey(key []byte) error {
+	if len(key) != KeyLen {
+		return ErrBadKeyLen
+	}
+	var acc byte
+	for i := 0; i < len(key); i++ {
+		acc |= key[i]
+	}
+	if acc == 0 {
+		return ErrZeroKey
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package doubleclick
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	formats := map[string]string{
+		"web-safe":        base64.RawURLEncoding.EncodeToString(encryptionKey),
+		"web-safe padded": base64.URLEncoding.EncodeToString(encryptionKey),
+		"base64":          base64.StdEncoding.EncodeToString(encryptionKey),
+		"hex":             hex.EncodeToString(encryptionKey),
+		"hex prefixed":    "0x" + strings.ToUpper(hex.EncodeToString(encryptionKey)),
+		"raw":             string(encryptionKey),
+		"spaces":          " " + base64.URLEncoding.EncodeToString(encryptionKey) + "\n",
+	}
+	for name, s := range formats {
+		key, err := ParseKey(nil, s)
+		if err != nil {
+			t.Error(name, err)
+		}
+		if !bytes.Equal(key, encryptionKey) {
+			t.Error("parse key failed:", name)
+		}
+	}
+	if _, err := ParseKey(nil, "  "); err != ErrEmptyKey {
+		t.Error("expected error", ErrEmptyKey)
+	}
+	if _, err := ParseKey(nil, "short"); err != ErrBadKeyFormat {
+		t.Error("expected error", ErrBadKeyFormat)
+	}
+	_, err := ParseKey(nil, hex.EncodeToString(encryptionKey[:16]))
+	if !errors.Is(err, ErrBadKeyLen) || err.Error() != "key must be 32 bytes long (got 16)" {
+		t.Error("expected error", ErrBadKeyLen, "got", err)
+	}
+}
+
+func TestParseKeyPair(t *testing.T) {
+	ek := base64.URLEncoding.EncodeToString(encryptionKey)
+	ik := base64.URLEncoding.EncodeToString(integrityKey)
+	kp, err := ParseKeyPair("v1", ek, ik)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kp.ID != "v1" || !bytes.Equal(kp.EncryptionKey, encryptionKey) || !bytes.Equal(kp.IntegrityKey, integrityKey) {
+		t.Error("parse key pair failed")
+	}
+
+	stages := []struct {
+		ek, ik string
+		err    error
+		msg    string
+	}{
+		{ek, "", ErrEmptyKey, "integrity key: empty key"},
+		{"!!", ik, ErrBadKeyFormat, "encryption key: unknown key format, expected base64, hex or raw bytes"},
+		{hex.EncodeToString(encryptionKey[:31]), ik, ErrBadKeyLen, "encryption key: key must be 32 bytes long (got 31)"},
+		// Truncated key must not be taken for raw bytes.
+		{ek, ik[:32], ErrBadKeyLen, "integrity key: key must be 32 bytes long (got 24)"},
+		{base64.StdEncoding.EncodeToString(make([]byte, 32)), ik, ErrZeroKey, "encryption key: key consists of zeros"},
+		{ek, ek, ErrSameKeys, "integrity key: encryption and integrity keys are identical"},
+	}
+	for _, st := range stages {
+		_, err := ParseKeyPair("", st.ek, st.ik)
+		if !errors.Is(err, st.err) {
+			t.Errorf("expected error %v, got %v", st.err, err)
+		}
+		if st.msg != "" && (err == nil || err.Error() != st.msg) {
+			t.Errorf("unexpected error message: %v", err)
+		}
+	}
+	err = KeyPair{EncryptionKey: encryptionKey[:31], IntegrityKey: integrityKey}.Validate()
+	if !errors.Is(err, ErrBadKeyLen) || err.Error() != "encryption key: key must be 32 bytes long (got 31)" {
+		t.Error("unexpected error", err)
+	}
+}
+
+func TestNewFromStrings(t *testing.T) {
+	d, err := NewFromStrings(TypePrice, base64.URLEncoding.EncodeToString(encryptionKey), hex.EncodeToString(integrityKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	price, err := d.DecryptPrice(encryptedPrice, micros)
+	if err != nil {
+		t.Error(err)
+	}
+	if price != decryptedPrice {
+		t.Error("decrypt price failed")
+	}
+	if _, err = NewFromStrings(TypePrice, "", ""); !errors.Is(err, ErrEmptyKey) {
+		t.Error("expected error", ErrEmptyKey)
+	}
+}
+
+func BenchmarkParseKey(b *testing.B) {
+	s := base64.URLEncoding.EncodeToString(encryptionKey)
+	var dst []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dst, _ = ParseKey(dst[:0], s)
+	}
+}
@@ -0,0 +1,210 @@
+package doubleclick
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+// KeyedPool is a pool of DC instances with separate free lists per key pair and type.
+//
+// Unlike Pool, instances taken from keyed pool are always bound to the requested keys and type, so they keep their
+// HMAC key schedules between uses and never carry keys of other tenants.
+type KeyedPool struct {
+	// Policy is a decryption policy applied to all instances taken from the pool.
+	// Must be set before first use of the pool.
+	Policy *Policy
+	// MaxKeys limits number of distinct key pairs (and types) kept in the pool. When the limit is reached, a free list
+	// is evicted using second-chance algorithm, so lists used since the previous eviction are spared once. Zero means no
+	// limit.
+	// Must be set before first use of the pool.
+	MaxKeys int
+
+	mux sync.RWMutex
+	// Free lists indexed by fingerprint of keys and type.
+	lists map[uint64][]*keyedList
+	n     int
+}
+
+// Free list of instances with the same keys and type.
+type keyedList struct {
+	typ        Type
+	ekey, ikey []byte
+	// Prebuilt key schedules copied to new instances.
+	hmacE, hmacI hmacKey
+	// Second chance flag of eviction.
+	used uint32
+	// List was evicted, its instances must not be put back.
+	evicted uint32
+	p       sync.Pool
+}
+
+// Get DC instance bound to given type and keys from the pool.
+func (p *KeyedPool) Get(typ Type, encryptionKey, integrityKey []byte) *DoubleClick {
+	l := p.list(typ, encryptionKey, integrityKey)
+	if v := l.p.Get(); v != nil {
+		if x, ok := v.(*DoubleClick); ok {
+			x.SetPolicy(p.Policy)
+			return x
+		}
+	}
+	x := &DoubleClick{
+		typ:   typ,
+		ekey:  append([]byte(nil), l.ekey...),
+		ikey:  append([]byte(nil), l.ikey...),
+		hmacE: l.hmacE.owned(),
+		hmacI: l.hmacI.owned(),
+		keyed: true,
+		list:  l,
+	}
+	x.SetPolicy(p.Policy)
+	return x
+}
+
+// Put instance of DC back to the pool.
+//
+// Instances of evicted free lists and instances which keys were changed after Get are dropped.
+func (p *KeyedPool) Put(x *DoubleClick) {
+	l := x.list
+	if l == nil || atomic.LoadUint32(&l.evicted) != 0 ||
+		!bytes.Equal(x.ekey, l.ekey) || !bytes.Equal(x.ikey, l.ikey) {
+		return
+	}
+	x.Reset()
+	// Price methods may overwrite type.
+	x.typ = l.typ
+	x.SetInitVectorGen(nil)
+	x.SetPolicy(nil)
+	x.SetRounding(RoundTrunc)
+	x.SetEncoding(EncodingRaw)
+	l.p.Put(x)
+}
+
+// Len returns number of distinct key pairs (and types) kept in the pool.
+func (p *KeyedPool) Len() int {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	return p.n
+}
+
+// SetAll evicts free lists which keys are missing in given key pairs.
+//
+// Instances with stale keys aren't reused after keys rotation, so the pool may be used as a KeySink of Reloader.
+func (p *KeyedPool) SetAll(keys map[string]KeyPair) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for fp, chain := range p.lists {
+		var kept []*keyedList
+		for _, l := range chain {
+			if hasKeyPair(keys, l.ekey, l.ikey) {
+				kept = append(kept, l)
+				continue
+			}
+			atomic.StoreUint32(&l.evicted, 1)
+			p.n--
+		}
+		if len(kept) == 0 {
+			delete(p.lists, fp)
+		} else {
+			p.lists[fp] = kept
+		}
+	}
+}
+
+// Get or make free list of given type and keys.
+func (p *KeyedPool) list(typ Type, encryptionKey, integrityKey []byte) *keyedList {
+	fp := keysFingerprint(typ, encryptionKey, integrityKey)
+	p.mux.RLock()
+	l := p.find(fp, typ, encryptionKey, integrityKey)
+	p.mux.RUnlock()
+	if l != nil {
+		// Avoid writes to shared memory if flag is already set.
+		if atomic.LoadUint32(&l.used) == 0 {
+			atomic.StoreUint32(&l.used, 1)
+		}
+		return l
+	}
+
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if l = p.find(fp, typ, encryptionKey, integrityKey); l != nil {
+		return l
+	}
+	if p.MaxKeys > 0 && p.n >= p.MaxKeys {
+		p.evict()
+	}
+	l = &keyedList{
+		typ:   typ,
+		ekey:  append([]byte(nil), encryptionKey...),
+		ikey:  append([]byte(nil), integrityKey...),
+		hmacE: newHMACKey(encryptionKey),
+		hmacI: newHMACKey(integrityKey),
+	}
+	if p.lists == nil {
+		p.lists = make(map[uint64][]*keyedList)
+	}
+	p.lists[fp] = append(p.lists[fp], l)
+	p.n++
+	return l
+}
+
+// Find free list by fingerprint. Keys are compared as well, since fingerprints may collide.
+func (p *KeyedPool) find(fp uint64, typ Type, encryptionKey, integrityKey []byte) *keyedList {
+	for _, l := range p.lists[fp] {
+		if l.typ == typ && bytes.Equal(l.ekey, encryptionKey) && bytes.Equal(l.ikey, integrityKey) {
+			return l
+		}
+	}
+	return nil
+}
+
+// Evict one free list using second chance algorithm: lists used since the previous eviction are spared once.
+//
+// Must be called under write lock.
+func (p *KeyedPool) evict() {
+	for pass := 0; pass < 2; pass++ {
+		for fp, chain := range p.lists {
+			for i, l := range chain {
+				if pass == 0 && atomic.SwapUint32(&l.used, 0) != 0 {
+					continue
+				}
+				atomic.StoreUint32(&l.evicted, 1)
+				if len(chain) == 1 {
+					delete(p.lists, fp)
+				} else {
+					p.lists[fp] = append(chain[:i:i], chain[i+1:]...)
+				}
+				p.n--
+				return
+			}
+		}
+	}
+}
+
+// Compute FNV-1a fingerprint of type and keys.
+func keysFingerprint(typ Type, encryptionKey, integrityKey []byte) uint64 {
+	h := uint64(fnvOffset)
+	mix := func(b byte) {
+		h ^= uint64(b)
+		h *= fnvPrime
+	}
+	mix(byte(typ))
+	mix(byte(len(encryptionKey)))
+	for i := 0; i < len(encryptionKey); i++ {
+		mix(encryptionKey[i])
+	}
+	for i := 0; i < len(integrityKey); i++ {
+		mix(integrityKey[i])
+	}
+	return h
+}
+
+// Check if keys contain pair of given encryption and integrity keys.
+func hasKeyPair(keys map[string]KeyPair, encryptionKey, integrityKey []byte) bool {
+	for _, kp := range keys {
+		if bytes.Equal(kp.EncryptionKey, encryptionKey) && bytes.Equal(kp.IntegrityKey, integrityKey) {
+			return true
+		}
+	}
+	return false
+}
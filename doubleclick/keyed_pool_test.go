@@ -0,0 +1,121 @@
+package doubleclick
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyedPool(t *testing.T) {
+	t.Run("tenants", func(t *testing.T) {
+		var p KeyedPool
+		for i := 0; i < 3; i++ {
+			d := p.Get(TypeAdID, encryptionKey, integrityKey)
+			dst, err := d.Decrypt(nil, encryptedAdID)
+			if err != nil {
+				t.Error(err)
+			}
+			if !bytes.Equal(dst, decryptedAdID) {
+				t.Error("decrypt AdID failed")
+			}
+			p.Put(d)
+
+			d = p.Get(TypeAdID, encryptionKey1, integrityKey1)
+			if _, err = d.Decrypt(nil, encryptedAdID); err != ErrSignCheckFail {
+				t.Error("expected error", ErrSignCheckFail)
+			}
+			p.Put(d)
+		}
+		if p.Len() != 2 {
+			t.Error("unexpected number of keys", p.Len())
+		}
+	})
+	t.Run("type", func(t *testing.T) {
+		var p KeyedPool
+		d := p.Get(TypeAdID, encryptionKey, integrityKey)
+		if _, err := d.EncryptPrice(decryptedPrice, nil, initVector, micros); err != nil {
+			t.Error(err)
+		}
+		p.Put(d)
+		if d.typ != TypeAdID {
+			t.Error("type not restored")
+		}
+		d = p.Get(TypePrice, encryptionKey, integrityKey)
+		if d.typ != TypePrice || p.Len() != 2 {
+			t.Error("type mismatch")
+		}
+	})
+	t.Run("rekeyed", func(t *testing.T) {
+		var p KeyedPool
+		d := p.Get(TypeAdID, encryptionKey, integrityKey)
+		d.SetKeys(encryptionKey1, integrityKey1)
+		p.Put(d)
+		for i := 0; i < 10; i++ {
+			x := p.Get(TypeAdID, encryptionKey, integrityKey)
+			if x == d {
+				t.Fatal("rekeyed instance returned to the pool")
+			}
+			p.Put(x)
+		}
+	})
+	t.Run("max keys", func(t *testing.T) {
+		p := KeyedPool{MaxKeys: 4}
+		var keys [][]byte
+		for i := 0; i < 10; i++ {
+			key := bytes.Repeat([]byte{byte(i + 1)}, 32)
+			keys = append(keys, key)
+			d := p.Get(TypeAdID, key, integrityKey)
+			p.Put(d)
+			if p.Len() > 4 {
+				t.Fatal("keys limit exceeded", p.Len())
+			}
+		}
+		// Instance of evicted list must be dropped.
+		p = KeyedPool{MaxKeys: 1}
+		d := p.Get(TypeAdID, keys[0], integrityKey)
+		p.Put(p.Get(TypeAdID, keys[1], integrityKey))
+		if p.Len() != 1 || d.list.evicted == 0 {
+			t.Error("list expected to be evicted")
+		}
+		p.Put(d)
+		if dst, err := p.Get(TypeAdID, encryptionKey, integrityKey).Decrypt(nil, encryptedAdID); err != nil || !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed", err)
+		}
+	})
+	t.Run("stale keys", func(t *testing.T) {
+		var p KeyedPool
+		d := p.Get(TypeAdID, encryptionKey1, integrityKey1)
+		p.Put(p.Get(TypePrice, encryptionKey, integrityKey))
+		p.SetAll(map[string]KeyPair{"seat": {EncryptionKey: encryptionKey, IntegrityKey: integrityKey}})
+		if p.Len() != 1 || d.list.evicted == 0 {
+			t.Error("stale list expected to be evicted")
+		}
+		p.Put(d)
+		if x := p.Get(TypeAdID, encryptionKey1, integrityKey1); x == d || p.Len() != 2 {
+			t.Error("instance with stale keys returned to the pool")
+		}
+	})
+}
+
+func BenchmarkKeyedPool(b *testing.B) {
+	tenants := [][]byte{encryptionKey, encryptionKey1}
+	b.Run("keyed", func(b *testing.B) {
+		var p KeyedPool
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := p.Get(TypePrice, tenants[i&1], integrityKey)
+			_, _ = d.DecryptPrice(encryptedPrice, micros)
+			p.Put(d)
+		}
+	})
+	b.Run("plain", func(b *testing.B) {
+		var p Pool
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			d := p.Get(TypePrice, tenants[i&1], integrityKey)
+			_, _ = d.DecryptPrice(encryptedPrice, micros)
+			p.Put(d)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package doubleclick
+
+// Keyring is a set of key pairs that may be used simultaneously, e.g. during keys rollover.
+//
+// Encryption always uses the primary (first) key pair, but decryption tries all key pairs in order until signature
+// check succeeds.
+//
+// Keyring isn't thread-safe, as well as DoubleClick.
+type Keyring struct {
+	typ  Type
+	keys []keyringEntry
+}
+
+type keyringEntry struct {
+	id string
+	dc *DoubleClick
+}
+
+// NewKeyring makes new keyring with given type and key pairs.
+//
+// The first key pair becomes primary.
+func NewKeyring(typ Type, keys ...KeyPair) *Keyring {
+	k := &Keyring{typ: typ}
+	for i := 0; i < len(keys); i++ {
+		k.Add(keys[i])
+	}
+	return k
+}
+
+// Add appends key pair to the end of the keyring.
+func (k *Keyring) Add(key KeyPair) {
+	k.keys = append(k.keys, keyringEntry{
+		id: key.ID,
+		dc: New(k.typ, key.EncryptionKey, key.IntegrityKey),
+	})
+}
+
+// Len returns count of key pairs in the keyring.
+func (k *Keyring) Len() int {
+	return len(k.keys)
+}
+
+// Encrypt encrypts plain to dst using primary key pair.
+func (k *Keyring) Encrypt(dst, initVec, plain []byte) ([]byte, error) {
+	return k.EncryptFn(dst, initVec, plain, nil)
+}
+
+// EncryptFn encrypts plain to dst using primary key pair and apply post-encryption convert func.
+func (k *Keyring) EncryptFn(dst, initVec, plain []byte, convFn ConvFn) ([]byte, error) {
+	if len(k.keys) == 0 {
+		return dst, ErrNoKeys
+	}
+	return k.keys[0].dc.EncryptFn(dst, initVec, plain, convFn)
+}
+
+// EncryptPrice encrypts price using primary key pair.
+func (k *Keyring) EncryptPrice(price float64, dst, initVec []byte, micros int) ([]byte, error) {
+	if len(k.keys) == 0 {
+		return dst, ErrNoKeys
+	}
+	// Price encryption switches type of the tool, so restore it for further calls.
+	dc := k.keys[0].dc
+	typ := dc.typ
+	dst, err := dc.EncryptPrice(price, dst, initVec, micros)
+	dc.typ = typ
+	return dst, err
+}
+
+// Decrypt decrypts cipher to dst using the first suitable key pair.
+//
+// Returns ID of matched key pair as second value.
+func (k *Keyring) Decrypt(dst, cipher []byte) ([]byte, string, error) {
+	return k.DecryptFn(dst, cipher, nil)
+}
+
+// DecryptFn decrypts cipher to dst using the first suitable key pair and apply post-decryption convert func.
+//
+// Returns ID of matched key pair as second value.
+func (k *Keyring) DecryptFn(dst, cipher []byte, convFn ConvFn) ([]byte, string, error) {
+	if len(k.keys) == 0 {
+		return dst, "", ErrNoKeys
+	}
+	err := ErrSignCheckFail
+	for i := 0; i < len(k.keys); i++ {
+		e := &k.keys[i]
+		if dst, err = e.dc.DecryptFn(dst, cipher, convFn); err != ErrSignCheckFail {
+			if err != nil {
+				return dst, "", err
+			}
+			return dst, e.id, nil
+		}
+	}
+	return dst, "", err
+}
+
+// DecryptPrice decrypts price using the first suitable key pair.
+//
+// Returns ID of matched key pair as second value.
+func (k *Keyring) DecryptPrice(cipher []byte, micros int) (float64, string, error) {
+	if len(k.keys) == 0 {
+		return 0, "", ErrNoKeys
+	}
+	var (
+		price float64
+		err   error
+	)
+	for i := 0; i < len(k.keys); i++ {
+		e := &k.keys[i]
+		if price, err = e.dc.DecryptPrice(cipher, micros); err != ErrSignCheckFail {
+			if err != nil {
+				return 0, "", err
+			}
+			return price, e.id, nil
+		}
+	}
+	return 0, "", err
+}
+
+// Reset buffers of all key pairs.
+func (k *Keyring) Reset() {
+	for i := 0; i < len(k.keys); i++ {
+		k.keys[i].dc.Reset()
+	}
+}
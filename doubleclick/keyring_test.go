@@ -0,0 +1,106 @@
+package doubleclick
+
+import (
+	"bytes"
+	"testing"
+)
+
+var (
+	keyPairOld = KeyPair{ID: "old", EncryptionKey: encryptionKey, IntegrityKey: integrityKey}
+	keyPairNew = KeyPair{ID: "new", EncryptionKey: encryptionKey1, IntegrityKey: integrityKey1}
+)
+
+func TestKeyring(t *testing.T) {
+	t.Run("decrypt", func(t *testing.T) {
+		k := NewKeyring(TypeAdID, keyPairNew, keyPairOld)
+		dst, id, err := k.Decrypt(nil, encryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if id != "old" {
+			t.Error("key ID mismatch, got", id)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		k := NewKeyring(TypeAdID, keyPairNew, keyPairOld)
+		enc, err := k.Encrypt(nil, initVector, decryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if bytes.Equal(enc, encryptedAdID) {
+			t.Error("encrypt must use primary key")
+		}
+		dst, id, err := k.Decrypt(nil, enc)
+		if err != nil {
+			t.Error(err)
+		}
+		if id != "new" {
+			t.Error("key ID mismatch, got", id)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed")
+		}
+	})
+	t.Run("price", func(t *testing.T) {
+		k := NewKeyring(TypePrice, keyPairNew, keyPairOld)
+		price, id, err := k.DecryptPrice(encryptedPrice, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if id != "old" {
+			t.Error("key ID mismatch, got", id)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+	})
+	t.Run("encrypt price", func(t *testing.T) {
+		k := NewKeyring(TypeAdID, keyPairNew, keyPairOld)
+		if _, err := k.EncryptPrice(decryptedPrice, nil, initVector, micros); err != nil {
+			t.Error(err)
+		}
+		// Type of the keyring must survive price encryption.
+		if _, id, err := k.Decrypt(nil, encryptedAdID); err != nil || id != "old" {
+			t.Error("decrypt AdID after price encryption failed:", err)
+		}
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		k := NewKeyring(TypeAdID, keyPairNew)
+		if _, _, err := k.Decrypt(nil, encryptedAdID); err != ErrSignCheckFail {
+			t.Error("expected error", ErrSignCheckFail)
+		}
+		if _, _, err := k.Decrypt(nil, encryptedIDFA); err != ErrBadMsgLen {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		k := NewKeyring(TypeAdID)
+		if _, _, err := k.Decrypt(nil, encryptedAdID); err != ErrNoKeys {
+			t.Error("expected error", ErrNoKeys)
+		}
+		if _, err := k.Encrypt(nil, initVector, decryptedAdID); err != ErrNoKeys {
+			t.Error("expected error", ErrNoKeys)
+		}
+	})
+}
+
+func BenchmarkKeyring(b *testing.B) {
+	b.Run("decrypt", func(b *testing.B) {
+		k := NewKeyring(TypePrice, keyPairNew, keyPairOld)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			price, _, err := k.DecryptPrice(encryptedPrice, micros)
+			if err != nil {
+				b.Error(err)
+			}
+			if price != decryptedPrice {
+				b.Error("decrypt price failed")
+			}
+			k.Reset()
+		}
+	})
+}
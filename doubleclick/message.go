@@ -0,0 +1,82 @@
+package doubleclick
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Message is a read-only view of encrypted message.
+//
+// Allows to inspect message parts without decryption.
+type Message struct {
+	buf []byte
+}
+
+// Inspect parses encrypted message.
+//
+// Message may be raw or web-safe base64 encoded (with or without paddings). Message is taken as base64 if it decodes
+// to the length of registered fixed length type, or if it decodes and its raw length doesn't match any such type.
+// Otherwise, message is taken as raw even if all its bytes fall into base64 alphabet; use InspectEncoding if the
+// encoding is known. Message is copied, so source may be reused after call.
+func Inspect(msg []byte) (Message, error) {
+	if isBase64(msg) {
+		if buf, err := b64DecodeAny(nil, msg); err == nil {
+			_, errDec := detectType(len(buf), nil)
+			_, errRaw := detectType(len(msg), nil)
+			if errDec != ErrUnkMsgType || errRaw == ErrUnkMsgType {
+				if len(buf) <= msgOverhead {
+					return Message{}, ErrBadMsgLen
+				}
+				return Message{buf: buf}, nil
+			}
+		}
+	}
+	return InspectEncoding(msg, EncodingRaw)
+}
+
+// InspectEncoding parses encrypted message in given wire encoding.
+//
+// Message is copied, so source may be reused after call.
+func InspectEncoding(msg []byte, enc Encoding) (Message, error) {
+	var (
+		m   Message
+		err error
+	)
+	if m.buf, err = enc.Decode(nil, msg); err != nil {
+		return Message{}, err
+	}
+	if len(m.buf) <= msgOverhead {
+		return Message{}, ErrBadMsgLen
+	}
+	return m, nil
+}
+
+// InitVector returns init vector of the message.
+func (m Message) InitVector() []byte {
+	return m.buf[initVectorOffset:initVectorLen]
+}
+
+// Cipher returns encrypted payload of the message.
+func (m Message) Cipher() []byte {
+	return m.buf[cipherOffset : len(m.buf)-integritySignLen]
+}
+
+// Signature returns integrity signature of the message.
+func (m Message) Signature() []byte {
+	return m.buf[len(m.buf)-integritySignLen:]
+}
+
+// Time returns timestamp encoded to the init vector.
+func (m Message) Time() time.Time {
+	return initVectorTime(m.InitVector())
+}
+
+// ServerID returns server ID encoded to the init vector.
+func (m Message) ServerID() uint64 {
+	return binary.BigEndian.Uint64(m.buf[initVectorServerIDOffset : initVectorServerIDOffset+initVectorServerIDLen])
+}
+
+// Len returns length of raw message.
+func (m Message) Len() int {
+	return len(m.buf)
+}
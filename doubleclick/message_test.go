@@ -0,0 +1,107 @@
+package doubleclick
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	check := func(t *testing.T, m Message) {
+		if !bytes.Equal(m.InitVector(), initVector) {
+			t.Error("init vector mismatch")
+		}
+		if !bytes.Equal(m.Cipher(), encryptedPrice[cipherOffset:cipherOffset+payloadLenPrice]) {
+			t.Error("cipher mismatch")
+		}
+		if !bytes.Equal(m.Signature(), encryptedPrice[msgLenPrice-integritySignLen:]) {
+			t.Error("signature mismatch")
+		}
+		if !m.Time().Equal(initVectorTs) {
+			t.Error("time mismatch, got", m.Time())
+		}
+		if m.ServerID() != 0x0123456789abcdef {
+			t.Errorf("server ID mismatch, got %x", m.ServerID())
+		}
+	}
+	t.Run("raw", func(t *testing.T) {
+		m, err := Inspect(encryptedPrice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, m)
+	})
+	t.Run("web-safe", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		ws, _ := d.WebSafeEncode(nil, encryptedPrice)
+		m, err := Inspect(ws)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, m)
+	})
+	t.Run("web-safe padded", func(t *testing.T) {
+		m, err := Inspect([]byte("OG46wAAMCggBI0VniavN7-mNy0VTKPbB3o5CMQ=="))
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, m)
+	})
+	t.Run("raw in base64 alphabet", func(t *testing.T) {
+		msg := []byte("0123456789abcdefCIPHERTXsign")
+		if _, err := detectType(21, nil); err != ErrUnkMsgType {
+			// Some test registered type with 1-byte payload, so message is ambiguous by design.
+			t.Skip("base64 decoded length matches registered type")
+		}
+		m, err := Inspect(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(m.InitVector()) != "0123456789abcdef" || string(m.Cipher()) != "CIPHERTX" || string(m.Signature()) != "sign" {
+			t.Error("raw message taken as base64")
+		}
+	})
+	t.Run("encoded lengths", func(t *testing.T) {
+		// Base64 encoded messages of fixed length types must not be taken as raw.
+		for _, typ := range []Type{TypePrice, TypeIDFA, TypeAdID, TypeHyperlocal} {
+			ti, _ := lookupType(typ)
+			msg := make([]byte, ti.payloadLen+msgOverhead)
+			for _, enc := range []Encoding{EncodingWebSafe, EncodingWebSafePadded} {
+				m, err := Inspect(enc.Encode(nil, msg))
+				if err != nil || m.Len() != len(msg) {
+					t.Errorf("%s message of type %d inspected as raw", enc, typ)
+				}
+			}
+		}
+	})
+	t.Run("encoded length matches raw length", func(t *testing.T) {
+		// Padded web-safe hyperlocal message has the same length as raw message of test type.
+		typ := registerTestType(t)
+		ti, _ := lookupType(typ)
+		msg := make([]byte, payloadLenHyperlocal+msgOverhead)
+		ws := EncodingWebSafePadded.Encode(nil, msg)
+		if len(ws) != ti.payloadLen+msgOverhead {
+			t.Fatal("test type length mismatch")
+		}
+		m, err := Inspect(ws)
+		if err != nil || m.Len() != len(msg) {
+			t.Error("web-safe-padded hyperlocal message inspected as raw")
+		}
+	})
+	t.Run("encoding", func(t *testing.T) {
+		for _, enc := range []Encoding{EncodingRaw, EncodingWebSafe, EncodingBase64, EncodingHex, EncodingPercent} {
+			m, err := InspectEncoding(enc.Encode(nil, encryptedPrice), enc)
+			if err != nil {
+				t.Fatal(enc, err)
+			}
+			check(t, m)
+		}
+		if _, err := InspectEncoding([]byte("zz"), EncodingHex); err != ErrBadHex {
+			t.Error("expected error", ErrBadHex)
+		}
+	})
+	t.Run("too short", func(t *testing.T) {
+		if _, err := Inspect(encryptedPrice[:msgOverhead]); err != ErrBadMsgLen {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package doubleclick
+
+import "time"
+
+// Policy is a decryption policy.
+//
+// Policy validates timestamp encoded to the init vector of successfully decrypted messages and allows to reject too
+// old or future-dated messages. Optionally detects replays of the same message.
+type Policy struct {
+	// MaxAge is a max allowed age of the message.
+	// Zero value disables the check.
+	MaxAge time.Duration
+	// Skew is an allowed clock skew. Messages with timestamps later than now+Skew are rejected.
+	// Timestamps are checked only if MaxAge or Skew is set, so policy with Replay only accepts any timestamps.
+	Skew time.Duration
+	// Clock is a time source.
+	// If omitted time.Now will be used.
+	Clock func() time.Time
+	// Replay is a detector of replayed messages.
+	// If omitted replays aren't checked.
+	Replay *ReplayCache
+}
+
+// Check init vector timestamp and signature according policy rules.
+func (p *Policy) check(initVec, sign []byte) error {
+	if p.MaxAge > 0 || p.Skew > 0 {
+		var now time.Time
+		if p.Clock != nil {
+			now = p.Clock()
+		} else {
+			now = time.Now()
+		}
+		t := initVectorTime(initVec)
+		if t.After(now.Add(p.Skew)) {
+			return ErrFromFuture
+		}
+		if p.MaxAge > 0 && t.Before(now.Add(-p.MaxAge)) {
+			return ErrExpired
+		}
+	}
+	if p.Replay != nil {
+		return p.Replay.Check(initVec, sign)
+	}
+	return nil
+}
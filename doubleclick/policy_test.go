@@ -0,0 +1,63 @@
+package doubleclick
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy(t *testing.T) {
+	clock := func(d time.Duration) func() time.Time {
+		return func() time.Time { return initVectorTs.Add(d) }
+	}
+	t.Run("fresh", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		d.SetPolicy(&Policy{MaxAge: time.Minute, Clock: clock(time.Second)})
+		price, err := d.DecryptPrice(encryptedPrice, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		d.SetPolicy(&Policy{MaxAge: time.Minute, Clock: clock(time.Hour)})
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != ErrExpired {
+			t.Error("expected error", ErrExpired)
+		}
+	})
+	t.Run("future", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		d.SetPolicy(&Policy{MaxAge: time.Minute, Skew: time.Second, Clock: clock(-2 * time.Second)})
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != ErrFromFuture {
+			t.Error("expected error", ErrFromFuture)
+		}
+	})
+	t.Run("skew", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		d.SetPolicy(&Policy{Skew: time.Second, Clock: clock(-time.Millisecond)})
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("replay only", func(t *testing.T) {
+		// Timestamps aren't checked without MaxAge and Skew.
+		d := New(TypePrice, encryptionKey, integrityKey)
+		d.SetPolicy(&Policy{Replay: NewReplayCache(16, time.Hour), Clock: clock(-time.Hour)})
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != nil {
+			t.Error(err)
+		}
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != ErrReplay {
+			t.Error("expected error", ErrReplay)
+		}
+	})
+	t.Run("pool", func(t *testing.T) {
+		p := Pool{Policy: &Policy{MaxAge: time.Minute, Clock: clock(time.Hour)}}
+		d := p.Get(TypePrice, encryptionKey, integrityKey)
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != ErrExpired {
+			t.Error("expected error", ErrExpired)
+		}
+		p.Put(d)
+	})
+}
@@ -3,6 +3,10 @@ package doubleclick
 import "sync"
 
 type Pool struct {
+	// Policy is a decryption policy applied to all instances taken from the pool.
+	// Must be set before first use of the pool.
+	Policy *Policy
+
 	p sync.Pool
 }
 
@@ -15,16 +19,22 @@ func (p *Pool) Get(typ Type, encryptionKey, integrityKey []byte) *DoubleClick {
 		if x, ok := v.(*DoubleClick); ok {
 			x.typ = typ
 			x.SetKeys(encryptionKey, integrityKey)
+			x.SetPolicy(p.Policy)
 			return x
 		}
 	}
 	x := New(typ, encryptionKey, integrityKey)
+	x.SetPolicy(p.Policy)
 	return x
 }
 
 // Put instance of DC to the pool.
 func (p *Pool) Put(x *DoubleClick) {
 	x.Reset()
+	x.SetInitVectorGen(nil)
+	x.SetPolicy(nil)
+	x.SetRounding(RoundTrunc)
+	x.SetEncoding(EncodingRaw)
 	p.p.Put(x)
 }
 
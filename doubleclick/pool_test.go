@@ -0,0 +1,91 @@
+package doubleclick
+
+import (
+	"bytes"
+	"testing"
+)
+
+var (
+	encryptionKey1 = []byte{
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10,
+		0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x20,
+	}
+	integrityKey1 = []byte{
+		0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f, 0x30,
+		0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e, 0x3f, 0x40,
+	}
+)
+
+func TestSetKeys(t *testing.T) {
+	d := New(TypeAdID, encryptionKey1, integrityKey1)
+	if _, err := d.Decrypt(nil, encryptedAdID); err != ErrSignCheckFail {
+		t.Error("expected error", ErrSignCheckFail)
+	}
+	d.SetKeys(encryptionKey, integrityKey)
+	dst, err := d.Decrypt(nil, encryptedAdID)
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(dst, decryptedAdID) {
+		t.Error("decrypt AdID after keys rotation failed")
+	}
+}
+
+func TestPool(t *testing.T) {
+	t.Run("rotate keys", func(t *testing.T) {
+		var p Pool
+		d := p.Get(TypeAdID, encryptionKey1, integrityKey1)
+		enc1, err := d.Encrypt(nil, initVector, decryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		p.Put(d)
+
+		d = p.Get(TypeAdID, encryptionKey, integrityKey)
+		dst, err := d.Decrypt(nil, encryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed")
+		}
+		if _, err = d.Decrypt(nil, enc1); err != ErrSignCheckFail {
+			t.Error("expected error", ErrSignCheckFail)
+		}
+		p.Put(d)
+
+		d = p.Get(TypeAdID, encryptionKey1, integrityKey1)
+		if dst, err = d.Decrypt(dst[:0], enc1); err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed")
+		}
+		p.Put(d)
+	})
+	t.Run("acquire", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			ek, ik := encryptionKey, integrityKey
+			if i%2 == 1 {
+				ek, ik = encryptionKey1, integrityKey1
+			}
+			d := Acquire(TypePrice, ek, ik)
+			price, err := d.DecryptPrice(encryptedPrice, micros)
+			if i%2 == 0 {
+				if err != nil {
+					t.Error(err)
+				}
+				if price != decryptedPrice {
+					t.Error("decrypt price failed")
+				}
+			} else if err != ErrSignCheckFail {
+				t.Error("expected error", ErrSignCheckFail)
+			}
+			Release(d)
+		}
+	})
+	t.Run("release unused", func(t *testing.T) {
+		d := Acquire(TypeIDFA, encryptionKey, integrityKey)
+		Release(d)
+	})
+}
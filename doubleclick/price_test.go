@@ -2,6 +2,7 @@ package doubleclick
 
 import (
 	"bytes"
+	"math"
 	"testing"
 )
 
@@ -45,6 +46,151 @@ func TestPrice(t *testing.T) {
 	})
 }
 
+func TestPriceMicros(t *testing.T) {
+	t.Run("decrypt", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		price, err := d.DecryptPriceMicros(encryptedPrice)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != 1200000 {
+			t.Error("decrypt price micros failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		dst, err := d.EncryptPriceMicros(1200000, nil, initVector)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedPrice) {
+			t.Error("encrypt price micros failed")
+		}
+	})
+	t.Run("exact", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		dst, _ := d.EncryptPriceMicros(290000, nil, initVector)
+		x, err := d.DecryptPriceDecimal(dst, 6)
+		if err != nil {
+			t.Error(err)
+		}
+		if x.Value != 290000 || x.String() != "0.290000" {
+			t.Error("decrypt price decimal failed, got", x.String())
+		}
+	})
+}
+
+func TestPriceValidation(t *testing.T) {
+	d := New(TypePrice, encryptionKey, integrityKey)
+	for _, price := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := d.EncryptPrice(price, nil, initVector, micros); err != ErrBadPrice {
+			t.Error("expected error", ErrBadPrice, "for price", price)
+		}
+	}
+	if _, err := d.EncryptPrice(1e14, nil, initVector, micros); err != ErrPriceOverflow {
+		t.Error("expected error", ErrPriceOverflow)
+	}
+	if _, err := d.EncryptPrice(decryptedPrice, nil, initVector, 0); err != ErrBadMicros {
+		t.Error("expected error", ErrBadMicros)
+	}
+	if _, err := d.DecryptPrice(encryptedPrice, -1); err != ErrBadMicros {
+		t.Error("expected error", ErrBadMicros)
+	}
+
+	// Messages of other types must not be decrypted as price.
+	for _, typ := range []Type{TypeRaw, TypeHyperlocal} {
+		r := New(typ, encryptionKey, integrityKey)
+		msg := encryptedHyperlocal
+		if typ == TypeRaw {
+			msg, _ = r.Encrypt(nil, initVector, []byte{0x01})
+		}
+		if _, err := r.DecryptPrice(msg, micros); err != ErrBadMsgLen {
+			t.Error(typ, "expected error", ErrBadMsgLen)
+		}
+		if _, err := r.DecryptPriceMicros(msg); err != ErrBadMsgLen {
+			t.Error(typ, "expected error", ErrBadMsgLen)
+		}
+		if _, _, err := r.DecryptPriceDetect(msg, micros); err != ErrBadMsgLen {
+			t.Error(typ, "expected error", ErrBadMsgLen)
+		}
+		ws, _ := r.WebSafeEncode(nil, msg)
+		if _, err := r.DecryptPriceWebSafe(ws, micros); err != ErrBadMsgLen {
+			t.Error(typ, "expected error", ErrBadMsgLen)
+		}
+	}
+}
+
+func TestPriceRounding(t *testing.T) {
+	stages := []struct {
+		price  float64
+		micros int
+		round  Rounding
+		expect uint64
+	}{
+		{0.57, 100, RoundTrunc, 56},
+		{0.57, 100, RoundHalfUp, 57},
+		{0.57, 100, RoundHalfEven, 57},
+		{2.5, 1, RoundHalfUp, 3},
+		{0.49999999999999994, 1, RoundHalfUp, 0},
+		{4503599627370497, 1, RoundHalfUp, 4503599627370497},
+		{2.5, 1, RoundHalfEven, 2},
+		{3.5, 1, RoundHalfEven, 4},
+		{2.5, 1, RoundTrunc, 2},
+	}
+	d := New(TypePrice, encryptionKey, integrityKey)
+	for _, st := range stages {
+		d.SetRounding(st.round)
+		dst, err := d.EncryptPrice(st.price, nil, initVector, st.micros)
+		if err != nil {
+			t.Error(err)
+		}
+		price, err := d.DecryptPriceMicros(dst)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != st.expect {
+			t.Errorf("price %v rounding %d: expect %d, got %d", st.price, st.round, st.expect, price)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	stages := []struct {
+		x      Decimal
+		digits int
+		expect string
+	}{
+		{Decimal{1200000, 6}, 6, "1.200000"},
+		{Decimal{1200000, 6}, 2, "1.20"},
+		{Decimal{1200000, 6}, 0, "1"},
+		{Decimal{290000, 6}, 2, "0.29"},
+		{Decimal{1234, 6}, 6, "0.001234"},
+		{Decimal{1235, 6}, 5, "0.00124"},
+		{Decimal{1999999, 6}, 2, "2.00"},
+		{Decimal{999999, 6}, 0, "1"},
+		{Decimal{5, 0}, 3, "5.000"},
+		{Decimal{12, 1}, 4, "1.2000"},
+		{Decimal{0, 6}, 3, "0.000"},
+		{Decimal{1, 20}, 20, "0.00000000000000000001"},
+		{Decimal{15000000000000000000, 20}, 20, "0.15000000000000000000"},
+		{Decimal{15000000000000000000, 20}, 1, "0.2"},
+		{Decimal{18446744073709551615, 25}, 22, "0.0000018446744073709552"},
+		{Decimal{18446744073709551615, 40}, 2, "0.00"},
+		{Decimal{123, 21}, 25, "0.0000000000000000001230000"},
+	}
+	for _, st := range stages {
+		if s := string(st.x.AppendString(nil, st.digits)); s != st.expect {
+			t.Errorf("decimal %d/%d digits %d: expect %s, got %s", st.x.Value, st.x.Scale, st.digits, st.expect, s)
+		}
+	}
+	if s := (Decimal{1, 20}).String(); s != "0.00000000000000000001" {
+		t.Error("decimal string mismatch, got", s)
+	}
+	if f := (Decimal{1200000, 6}).Float64(); f != 1.2 {
+		t.Error("decimal to float failed, got", f)
+	}
+}
+
 func BenchmarkPrice(b *testing.B) {
 	b.Run("decrypt", func(b *testing.B) {
 		d := New(TypePrice, encryptionKey, integrityKey)
@@ -87,6 +233,17 @@ func BenchmarkPrice(b *testing.B) {
 	})
 }
 
+func BenchmarkDecimal(b *testing.B) {
+	b.Run("append", func(b *testing.B) {
+		x := Decimal{Value: 1234567, Scale: 6}
+		var dst []byte
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst = x.AppendString(dst[:0], 2)
+		}
+	})
+}
+
 func BenchmarkPriceParallel(b *testing.B) {
 	decFn := func(b *testing.B, n int) {
 		b.ResetTimer()
@@ -0,0 +1,242 @@
+package doubleclick
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// KeyProvider is a source of key pairs of tenants.
+type KeyProvider interface {
+	// Keys returns parsed and validated key pairs indexed by tenant ID.
+	Keys() (map[string]KeyPair, error)
+}
+
+// KeyVersioner is an optional interface of key providers that can cheaply detect changes of the source.
+//
+// Reloader skips loading of keys if version didn't change since the last successful load.
+type KeyVersioner interface {
+	// KeysVersion returns version of the key source, e.g. hash of modification times of files.
+	KeysVersion() (uint64, error)
+}
+
+// Names of key file fields.
+const (
+	keyFieldID         = "key_id"
+	keyFieldEncryption = "encryption_key"
+	keyFieldIntegrity  = "integrity_key"
+)
+
+// EnvKeyProvider loads keys from environment variables.
+//
+// Variables <Prefix><TENANT>_ENCRYPTION_KEY, <Prefix><TENANT>_INTEGRITY_KEY and optional <Prefix><TENANT>_KEY_ID
+// define keys of the tenant TENANT, variables without tenant part (<Prefix>ENCRYPTION_KEY, ...) define keys of tenant
+// with empty ID. Keys may be in any format supported by ParseKey.
+type EnvKeyProvider struct {
+	// Prefix of variable names, e.g. "ADX_".
+	Prefix string
+	// Environ returns environment variables in "key=value" form, os.Environ by default.
+	Environ func() []string
+}
+
+// Keys loads key pairs from environment variables.
+func (p EnvKeyProvider) Keys() (map[string]KeyPair, error) {
+	environ := p.Environ
+	if environ == nil {
+		environ = os.Environ
+	}
+	raw := make(map[string]map[string]string)
+	for _, kv := range environ() {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(kv[:i], p.Prefix) {
+			continue
+		}
+		name, value := kv[len(p.Prefix):i], kv[i+1:]
+		for _, field := range [...]string{keyFieldID, keyFieldEncryption, keyFieldIntegrity} {
+			suffix := strings.ToUpper(field)
+			if !strings.HasSuffix(name, suffix) {
+				continue
+			}
+			id := name[:len(name)-len(suffix)]
+			if len(id) > 0 {
+				if !strings.HasSuffix(id, "_") {
+					continue
+				}
+				id = id[:len(id)-1]
+			}
+			if raw[id] == nil {
+				raw[id] = make(map[string]string)
+			}
+			raw[id][field] = value
+		}
+	}
+	return parseTenantKeys(raw, "env "+p.Prefix)
+}
+
+// FileKeyProvider loads keys of all tenants from one JSON or YAML file.
+//
+// File format is detected by extension (.json, .yaml or .yml). The file is a mapping of tenant IDs to objects with
+// fields "encryption_key", "integrity_key" and optional "key_id", e.g.:
+//
+//	seat-1:
+//	  encryption_key: "skU7Ax_NL5pPAFyKdkfZjZz2-VhIN8bjj1rVFOaJ_5o="
+//	  integrity_key: "arO23ykdNqUQ5LEoQ0FVmPkBd7xB5CO89PDZlSjpFxo="
+type FileKeyProvider struct {
+	Path string
+}
+
+// Keys loads key pairs from the file.
+func (p FileKeyProvider) Keys() (map[string]KeyPair, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeKeyFile(p.Path, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Path, err)
+	}
+	raw := make(map[string]map[string]string, len(doc))
+	for id, v := range doc {
+		fields, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: tenant %q: %w", p.Path, id, ErrBadKeyFile)
+		}
+		if raw[id], err = keyFields(fields); err != nil {
+			return nil, fmt.Errorf("%s: tenant %q: %w", p.Path, id, err)
+		}
+	}
+	return parseTenantKeys(raw, p.Path)
+}
+
+// KeysVersion returns version of the file based on its modification time and size.
+func (p FileKeyProvider) KeysVersion() (uint64, error) {
+	fi, err := os.Stat(p.Path)
+	if err != nil {
+		return 0, err
+	}
+	return fileVersion(fnvOffset, fi), nil
+}
+
+// DirKeyProvider loads keys from a directory of per-tenant files.
+//
+// Each JSON or YAML file (.json, .yaml or .yml) contains keys of one tenant, tenant ID is a file name without
+// extension. The file is an object with fields "encryption_key", "integrity_key" and optional "key_id". Other files
+// and subdirectories are ignored.
+type DirKeyProvider struct {
+	Path string
+}
+
+// Keys loads key pairs from files of the directory.
+func (p DirKeyProvider) Keys() (map[string]KeyPair, error) {
+	entries, err := os.ReadDir(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]map[string]string, len(entries))
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || !isKeyFileExt(ext) {
+			continue
+		}
+		path := filepath.Join(p.Path, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := decodeKeyFile(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		id := strings.TrimSuffix(e.Name(), ext)
+		if _, ok := raw[id]; ok {
+			return nil, fmt.Errorf("%s: duplicate tenant %q", path, id)
+		}
+		if raw[id], err = keyFields(doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return parseTenantKeys(raw, p.Path)
+}
+
+// KeysVersion returns version of the directory based on names, modification times and sizes of key files.
+func (p DirKeyProvider) KeysVersion() (uint64, error) {
+	entries, err := os.ReadDir(p.Path)
+	if err != nil {
+		return 0, err
+	}
+	h := uint64(fnvOffset)
+	for _, e := range entries {
+		if e.IsDir() || !isKeyFileExt(filepath.Ext(e.Name())) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return 0, err
+		}
+		h = fileVersion(h, fi)
+	}
+	return h, nil
+}
+
+// Decode JSON or YAML key file according its extension.
+func decodeKeyFile(path string, data []byte) (map[string]interface{}, error) {
+	switch filepath.Ext(path) {
+	case ".json":
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	case ".yaml", ".yml":
+		return parseYAML(data)
+	default:
+		return nil, ErrBadKeyFile
+	}
+}
+
+func isKeyFileExt(ext string) bool {
+	return ext == ".json" || ext == ".yaml" || ext == ".yml"
+}
+
+// Extract string fields of key pair object.
+func keyFields(doc map[string]interface{}) (map[string]string, error) {
+	fields := make(map[string]string, 3)
+	for _, name := range [...]string{keyFieldID, keyFieldEncryption, keyFieldIntegrity} {
+		v, ok := doc[name]
+		if !ok {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %q must be a string: %w", name, ErrBadKeyFile)
+		}
+		fields[name] = s
+	}
+	return fields, nil
+}
+
+// Parse and validate raw key fields of tenants.
+func parseTenantKeys(raw map[string]map[string]string, source string) (map[string]KeyPair, error) {
+	keys := make(map[string]KeyPair, len(raw))
+	for id, fields := range raw {
+		kp, err := ParseKeyPair(fields[keyFieldID], fields[keyFieldEncryption], fields[keyFieldIntegrity])
+		if err != nil {
+			return nil, fmt.Errorf("%s: tenant %q: %w", source, id, err)
+		}
+		keys[id] = kp
+	}
+	return keys, nil
+}
+
+// Mix name, modification time and size of the file to FNV-1a hash h.
+func fileVersion(h uint64, fi os.FileInfo) uint64 {
+	s := fi.Name() + "\x00" + strconv.FormatInt(fi.ModTime().UnixNano(), 10) + "\x00" + strconv.FormatInt(fi.Size(), 10)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
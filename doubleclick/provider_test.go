@@ -0,0 +1,261 @@
+package doubleclick
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyProvider(t *testing.T) {
+	ek, ik := hex.EncodeToString(encryptionKey), base64.URLEncoding.EncodeToString(integrityKey)
+	ek1, ik1 := hex.EncodeToString(encryptionKey1), hex.EncodeToString(integrityKey1)
+	check := func(t *testing.T, keys map[string]KeyPair, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 2 {
+			t.Fatal("expected 2 tenants, got", len(keys))
+		}
+		if kp := keys["seat-1"]; string(kp.EncryptionKey) != string(encryptionKey) ||
+			string(kp.IntegrityKey) != string(integrityKey) || kp.ID != "v1" {
+			t.Error("keys of seat-1 mismatch")
+		}
+		if kp := keys["seat-2"]; string(kp.EncryptionKey) != string(encryptionKey1) ||
+			string(kp.IntegrityKey) != string(integrityKey1) {
+			t.Error("keys of seat-2 mismatch")
+		}
+	}
+
+	t.Run("env", func(t *testing.T) {
+		p := EnvKeyProvider{Prefix: "ADX_", Environ: func() []string {
+			return []string{
+				"PATH=/bin",
+				"ADX_seat-1_ENCRYPTION_KEY=" + ek,
+				"ADX_seat-1_INTEGRITY_KEY=" + ik,
+				"ADX_seat-1_KEY_ID=v1",
+				"ADX_seat-2_ENCRYPTION_KEY=" + ek1,
+				"ADX_seat-2_INTEGRITY_KEY=" + ik1,
+				"ADX_FOOENCRYPTION_KEY=" + ek,
+			}
+		}}
+		keys, err := p.Keys()
+		check(t, keys, err)
+
+		p.Environ = func() []string { return []string{"ADX_ENCRYPTION_KEY=" + ek} }
+		if _, err = p.Keys(); !errors.Is(err, ErrEmptyKey) {
+			t.Error("expected error", ErrEmptyKey, "got", err)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		writeFile(t, path, `{
+	"seat-1": {"encryption_key": "`+ek+`", "integrity_key": "`+ik+`", "key_id": "v1"},
+	"seat-2": {"encryption_key": "`+ek1+`", "integrity_key": "`+ik1+`"}
+}`)
+		keys, err := FileKeyProvider{Path: path}.Keys()
+		check(t, keys, err)
+	})
+	t.Run("yaml", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.yaml")
+		writeFile(t, path, `# Keys of seats.
+seat-1:
+  encryption_key: "`+ek+`"
+  integrity_key: `+ik+`
+  key_id: v1
+seat-2:
+  encryption_key: `+ek1+`
+  integrity_key: `+ik1+`
+`)
+		keys, err := FileKeyProvider{Path: path}.Keys()
+		check(t, keys, err)
+	})
+	t.Run("dir", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "seat-1.yml"), "encryption_key: "+ek+"\nintegrity_key: "+ik+"\nkey_id: v1\n")
+		writeFile(t, filepath.Join(dir, "seat-2.json"), `{"encryption_key": "`+ek1+`", "integrity_key": "`+ik1+`"}`)
+		writeFile(t, filepath.Join(dir, "readme.txt"), "not a key file")
+		p := DirKeyProvider{Path: dir}
+		keys, err := p.Keys()
+		check(t, keys, err)
+
+		v0, err := p.KeysVersion()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "seat-3.json"), `{"encryption_key": "`+ek+`", "integrity_key": "`+ik+`"}`)
+		if v1, _ := p.KeysVersion(); v1 == v0 {
+			t.Error("version must change after adding a file")
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		dir := t.TempDir()
+		stages := []struct {
+			name, data string
+			err        error
+		}{
+			{"keys.txt", "", ErrBadKeyFile},
+			{"keys.yaml", "seat:\n  - " + ek, ErrBadYAML},
+			{"keys.yaml", "seat: " + ek, ErrBadKeyFile},
+			{"keys.json", `{"seat": {"encryption_key": 1}}`, ErrBadKeyFile},
+			{"keys.json", `{"seat": {"encryption_key": "` + ek + `", "integrity_key": "` + ek + `"}}`, ErrSameKeys},
+			{"keys.json", `{"seat": {"encryption_key": "` + ek + `", "integrity_key": "!bad"}}`, ErrBadKeyFormat},
+		}
+		for _, st := range stages {
+			path := filepath.Join(dir, st.name)
+			writeFile(t, path, st.data)
+			if _, err := (FileKeyProvider{Path: path}).Keys(); !errors.Is(err, st.err) {
+				t.Errorf("%s: expected error %v, got %v", st.data, st.err, err)
+			}
+		}
+	})
+}
+
+func TestReloader(t *testing.T) {
+	ek, ik := hex.EncodeToString(encryptionKey), hex.EncodeToString(integrityKey)
+	ek1, ik1 := hex.EncodeToString(encryptionKey1), hex.EncodeToString(integrityKey1)
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFile(t, path, "seat:\n  encryption_key: "+ek1+"\n  integrity_key: "+ik1+"\n")
+
+	var (
+		tenants Tenants
+		pool    KeyedPool
+		mux     sync.Mutex
+		errs    []error
+	)
+	stale := pool.Get(TypePrice, encryptionKey1, integrityKey1)
+	r := Reloader{
+		Provider: FileKeyProvider{Path: path},
+		Sink: KeySinkFunc(func(keys map[string]KeyPair) {
+			pool.SetAll(keys)
+			tenants.SetAll(keys)
+		}),
+		Interval: time.Millisecond,
+		OnError: func(err error) {
+			mux.Lock()
+			errs = append(errs, err)
+			mux.Unlock()
+		},
+	}
+	if err := r.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+	if _, err := tenants.DecryptPriceFor("seat", encryptedPrice, micros); err != ErrSignCheckFail {
+		t.Error("expected error", ErrSignCheckFail)
+	}
+
+	// Swap keys.
+	writeFile(t, path, "seat:\n  encryption_key: "+ek+"\n  integrity_key: "+ik+"\n")
+	waitFor(t, func() bool {
+		_, err := tenants.DecryptPriceFor("seat", encryptedPrice, micros)
+		return err == nil
+	})
+	if pool.Len() != 0 || atomic.LoadUint32(&stale.list.evicted) == 0 {
+		t.Error("pooled instances with stale keys must be evicted")
+	}
+
+	// Broken file keeps previous keys. Polling may catch partially written file, so wait for the final error.
+	writeFile(t, path, "seat:\n  encryption_key: "+ek+"\n")
+	waitFor(t, func() bool {
+		mux.Lock()
+		defer mux.Unlock()
+		return len(errs) > 0 && errors.Is(errs[len(errs)-1], ErrEmptyKey)
+	})
+	if _, err := tenants.DecryptPriceFor("seat", encryptedPrice, micros); err != nil {
+		t.Error(err)
+	}
+
+	// Empty file keeps previous keys too.
+	writeFile(t, path, "")
+	waitFor(t, func() bool {
+		mux.Lock()
+		defer mux.Unlock()
+		return errs[len(errs)-1] == ErrNoKeys
+	})
+	if _, err := tenants.DecryptPriceFor("seat", encryptedPrice, micros); err != nil {
+		t.Error(err)
+	}
+
+	r.Stop()
+	r.Stop()
+}
+
+func TestReloaderEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFile(t, path, "seat:\n  encryption_key: "+hex.EncodeToString(encryptionKey)+
+		"\n  integrity_key: "+hex.EncodeToString(integrityKey)+"\n")
+	var tenants Tenants
+	r := Reloader{Provider: FileKeyProvider{Path: path}, Sink: &tenants}
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "")
+	if err := r.Reload(); err != ErrNoKeys {
+		t.Error("expected error", ErrNoKeys)
+	}
+	if _, err := tenants.DecryptPriceFor("seat", encryptedPrice, micros); err != nil {
+		t.Error(err)
+	}
+	// Wiping is opt-in.
+	r.AllowEmpty = true
+	if err := r.Reload(); err != nil {
+		t.Error(err)
+	}
+	if _, err := tenants.DecryptPriceFor("seat", encryptedPrice, micros); !errors.Is(err, ErrUnkTenant) {
+		t.Error("expected error", ErrUnkTenant)
+	}
+}
+
+func BenchmarkReloader(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "keys.json")
+	writeFile(b, path, `{"seat": {"encryption_key": "`+hex.EncodeToString(encryptionKey)+
+		`", "integrity_key": "`+hex.EncodeToString(integrityKey)+`"}}`)
+	var tenants Tenants
+	r := Reloader{Provider: FileKeyProvider{Path: path}, Sink: &tenants}
+	if err := r.Reload(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// Unchanged file is skipped.
+		if err := r.Reload(); err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+// Write file and bump its modification time to make the change visible regardless of timestamp resolution.
+func writeFile(tb testing.TB, path, data string) {
+	tb.Helper()
+	var mtime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		mtime = fi.ModTime()
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		tb.Fatal(err)
+	}
+	if !mtime.IsZero() {
+		if err := os.Chtimes(path, mtime, mtime.Add(time.Second)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("condition wasn't met in time")
+}
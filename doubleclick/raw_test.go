@@ -0,0 +1,191 @@
+package doubleclick
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"testing"
+)
+
+var (
+	decryptedRaw = []byte("Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor.")
+	encryptedRaw = encryptRawRef(decryptedRaw)
+)
+
+// Port of the reference implementation (DoubleClickCrypto.xorPayloadToHmacPad): counter bytes are kept after the pad
+// in the same array, only the last counter byte is incremented and the counter widens when it wraps.
+func encryptRawRef(plain []byte) []byte {
+	e, i := hmac.New(sha1.New, encryptionKey), hmac.New(sha1.New, integrityKey)
+	msg := append([]byte{}, initVector...)
+	var (
+		pad         [20 + 3]byte
+		counterSize int
+	)
+	for off := 0; off < len(plain); off += 20 {
+		e.Reset()
+		e.Write(initVector)
+		e.Write(pad[20 : 20+counterSize])
+		copy(pad[:20], e.Sum(nil))
+		for j := off; j < len(plain) && j < off+20; j++ {
+			msg = append(msg, plain[j]^pad[j-off])
+		}
+		if counterSize == 0 {
+			counterSize++
+		} else if pad[20+counterSize-1]++; pad[20+counterSize-1] == 0 {
+			counterSize++
+		}
+	}
+	i.Write(plain)
+	i.Write(initVector)
+	return append(msg, i.Sum(nil)[:4]...)
+}
+
+func TestRaw(t *testing.T) {
+	t.Run("decrypt", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		dst, err = d.Decrypt(dst, encryptedRaw)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, decryptedRaw) {
+			t.Error("decrypt raw failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		dst, err = d.Encrypt(dst, initVector, decryptedRaw)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedRaw) {
+			t.Error("encrypt raw failed")
+		}
+	})
+	t.Run("single block", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		dst, err := d.Encrypt(nil, initVector, decryptedHyperlocal)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedHyperlocal) {
+			t.Error("encrypt raw single block failed")
+		}
+	})
+	t.Run("counter", func(t *testing.T) {
+		stages := []struct {
+			block int
+			ctr   []byte
+		}{
+			{0, nil},
+			{1, []byte{0x00}},
+			{2, []byte{0x01}},
+			{256, []byte{0xff}},
+			{257, []byte{0x00, 0x00}},
+			{258, []byte{0x00, 0x01}},
+			{512, []byte{0x00, 0xff}},
+			{513, []byte{0x00, 0x00, 0x00}},
+			{768, []byte{0x00, 0x00, 0xff}},
+		}
+		var ctr [3]byte
+		for _, stage := range stages {
+			if c := padCounter(&ctr, stage.block); !bytes.Equal(c, stage.ctr) {
+				t.Errorf("block %d: counter mismatch: need %x got %x", stage.block, stage.ctr, c)
+			}
+		}
+	})
+	t.Run("long", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		for _, blocks := range []int{257, 258, 513, 514, maxPayloadLen / bufPadLen} {
+			plain := bytes.Repeat(decryptedRaw, blocks*bufPadLen/len(decryptedRaw)+1)[:blocks*bufPadLen-7]
+			msg, err := d.Encrypt(nil, initVector, plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(msg, encryptRawRef(plain)) {
+				t.Errorf("%d blocks: encrypt raw failed", blocks)
+			}
+			if dst, err := d.Decrypt(nil, msg); err != nil || !bytes.Equal(dst, plain) {
+				t.Errorf("%d blocks: decrypt raw failed: %v", blocks, err)
+			}
+		}
+	})
+	t.Run("bad length", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		if _, err := d.Encrypt(nil, initVector, nil); err != ErrBadPlainLen {
+			t.Error("expected error", ErrBadPlainLen)
+		}
+		if _, err := d.Decrypt(nil, encryptedRaw[:msgOverhead]); err != ErrBadMsgLen {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+		long := make([]byte, maxPayloadLen+1)
+		if _, err := d.Encrypt(nil, initVector, long); err != ErrBadPlainLen {
+			t.Error("expected error", ErrBadPlainLen)
+		}
+		if _, err := d.Decrypt(nil, append(long, make([]byte, msgOverhead)...)); err != ErrBadMsgLen {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+		c := NewCodec(TypeRaw, encryptionKey, integrityKey)
+		if _, err := c.Encrypt(nil, initVector, long); err != ErrBadPlainLen {
+			t.Error("expected error", ErrBadPlainLen)
+		}
+	})
+	t.Run("tampered", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		msg := append([]byte{}, encryptedRaw...)
+		msg[len(msg)-10] ^= 0xff
+		if _, err := d.Decrypt(nil, msg); err != ErrSignCheckFail {
+			t.Error("expected error", ErrSignCheckFail)
+		}
+	})
+}
+
+func BenchmarkRaw(b *testing.B) {
+	b.Run("decrypt", func(b *testing.B) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst = dst[:0]
+			dst, err = d.Decrypt(dst, encryptedRaw)
+			if err != nil {
+				b.Error(err)
+			}
+			if !bytes.Equal(dst, decryptedRaw) {
+				b.Error("decrypt raw failed")
+			}
+			d.Reset()
+		}
+	})
+	b.Run("encrypt", func(b *testing.B) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			dst = dst[:0]
+			dst, err = d.Encrypt(dst, initVector, decryptedRaw)
+			if err != nil {
+				b.Error(err)
+			}
+			if !bytes.Equal(dst, encryptedRaw) {
+				b.Error("encrypt raw failed")
+			}
+			d.Reset()
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package doubleclick
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Type info stored in the registry.
+type typeInfo struct {
+	name string
+	// Payload length, zero means variable length.
+	payloadLen int
+	// Default convert functions.
+	encFn, decFn ConvFn
+}
+
+var (
+	// Registry of types (copy-on-write slice indexed by type).
+	registry    atomic.Value
+	registryMux sync.Mutex
+)
+
+func init() {
+	registry.Store([]typeInfo{
+		TypeAdID:       {name: "adid", payloadLen: payloadLenAdID},
+		TypeIDFA:       {name: "idfa", payloadLen: payloadLenIDFA},
+		TypePrice:      {name: "price", payloadLen: payloadLenPrice},
+		TypeHyperlocal: {name: "hyperlocal", payloadLen: payloadLenHyperlocal},
+		TypeRaw:        {name: "raw"},
+	})
+}
+
+// RegisterType registers new type with given name and payload length.
+//
+// Zero payload length means variable length payloads (see TypeRaw). Optional encFn converts plain source to the
+// payload before encryption, optional decFn converts payload after decryption if no convert func passed explicitly.
+// Registration is thread-safe, but better to register types during init.
+func RegisterType(name string, payloadLen int, encFn, decFn ConvFn) (Type, error) {
+	if payloadLen < 0 {
+		return 0, ErrBadPlainLen
+	}
+	registryMux.Lock()
+	defer registryMux.Unlock()
+	types := registry.Load().([]typeInfo)
+	for i := 0; i < len(types); i++ {
+		if types[i].name == name {
+			return 0, ErrTypeExists
+		}
+	}
+	// Copy registry to keep lock-free reads safe.
+	cpy := make([]typeInfo, len(types), len(types)+1)
+	copy(cpy, types)
+	cpy = append(cpy, typeInfo{name: name, payloadLen: payloadLen, encFn: encFn, decFn: decFn})
+	registry.Store(cpy)
+	return Type(len(cpy) - 1), nil
+}
+
+// TypeByName returns registered type by its name.
+func TypeByName(name string) (Type, bool) {
+	types := registry.Load().([]typeInfo)
+	for i := 0; i < len(types); i++ {
+		if types[i].name == name {
+			return Type(i), true
+		}
+	}
+	return 0, false
+}
+
+// String returns name of the type.
+func (t Type) String() string {
+	if ti, ok := lookupType(t); ok {
+		return ti.name
+	}
+	return "unknown"
+}
+
+// Get type info from the registry.
+func lookupType(t Type) (typeInfo, bool) {
+	types := registry.Load().([]typeInfo)
+	if t < 0 || int(t) >= len(types) {
+		return typeInfo{}, false
+	}
+	return types[t], true
+}
@@ -0,0 +1,74 @@
+package doubleclick
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Test type: 24-byte bid ID transferred as hex string.
+func registerTestType(t testing.TB) Type {
+	if typ, ok := TypeByName("test-bid-id"); ok {
+		return typ
+	}
+	typ, err := RegisterType("test-bid-id", 24, convHexToPayload, ConvPayloadToHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typ
+}
+
+func convHexToPayload(dst, src []byte) []byte {
+	for i := 0; i+1 < len(src); i += 2 {
+		dst = append(dst, unhex(src[i])<<4|unhex(src[i+1]))
+	}
+	return dst
+}
+
+func TestRegistry(t *testing.T) {
+	typ := registerTestType(t)
+	plain := []byte("000102030405060708090a0b0c0d0e0f1011121314151617")
+	t.Run("names", func(t *testing.T) {
+		if TypePrice.String() != "price" || typ.String() != "test-bid-id" || Type(-1).String() != "unknown" {
+			t.Error("type name mismatch")
+		}
+		if _, err := RegisterType("price", 8, nil, nil); err != ErrTypeExists {
+			t.Error("expected error", ErrTypeExists)
+		}
+	})
+	t.Run("round trip", func(t *testing.T) {
+		d := New(typ, encryptionKey, integrityKey)
+		enc, err := d.Encrypt(nil, initVector, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(enc) != 24+msgOverhead {
+			t.Error("message length mismatch")
+		}
+		dst, err := d.Decrypt(nil, enc)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, plain) {
+			t.Error("decrypt custom type failed, got", string(dst))
+		}
+		dst, typ1, err := d.DecryptAny(dst[:0], enc)
+		if err != nil {
+			t.Error(err)
+		}
+		if typ1 != typ || !bytes.Equal(dst, plain) {
+			t.Error("decrypt any custom type failed")
+		}
+	})
+	t.Run("bad length", func(t *testing.T) {
+		d := New(typ, encryptionKey, integrityKey)
+		if _, err := d.Encrypt(nil, initVector, plain[:10]); err != ErrBadPlainLen {
+			t.Error("expected error", ErrBadPlainLen)
+		}
+	})
+	t.Run("unknown", func(t *testing.T) {
+		d := New(Type(1000), encryptionKey, integrityKey)
+		if _, err := d.Decrypt(nil, encryptedAdID); err != ErrUnkType {
+			t.Error("expected error", ErrUnkType)
+		}
+	})
+}
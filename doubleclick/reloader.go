@@ -0,0 +1,133 @@
+package doubleclick
+
+import (
+	"sync"
+	"time"
+)
+
+// KeySink receives reloaded key pairs of tenants.
+//
+// Tenants registry replaces its codecs by new keys, KeyedPool evicts free lists of stale keys.
+type KeySink interface {
+	// SetAll atomically replaces all key pairs.
+	SetAll(keys map[string]KeyPair)
+}
+
+// KeySinkFunc is an adapter to use ordinary function as a KeySink, e.g. to rebuild keyrings or feed several sinks.
+type KeySinkFunc func(keys map[string]KeyPair)
+
+// SetAll calls f(keys).
+func (f KeySinkFunc) SetAll(keys map[string]KeyPair) {
+	f(keys)
+}
+
+// Default interval of keys polling.
+const defaultReloadInterval = 30 * time.Second
+
+// Reloader polls key provider and swaps changed keys into the sink without restart.
+//
+// Keys are swapped atomically and only if the whole source was loaded and validated successfully; otherwise the sink
+// keeps previous keys and the error is reported to OnError callback. Providers implementing KeyVersioner are loaded
+// only when their version changes.
+//
+// Only the sink receives new keys. Instances made by New or taken from Pool, as well as Codec and Keyring, keep keys
+// they were built with, so resolve keys through Tenants to get hot reload, or rebuild own holders in KeySinkFunc.
+type Reloader struct {
+	// Provider is a source of keys.
+	Provider KeyProvider
+	// Sink receives loaded keys.
+	Sink KeySink
+	// Interval of polling, 30 seconds by default.
+	Interval time.Duration
+	// OnError is an optional callback of reload errors.
+	OnError func(err error)
+	// AllowEmpty allows empty sources (e.g. empty directory or file) to remove all key pairs from the sink. By
+	// default, empty sources are rejected with ErrNoKeys and the sink keeps previous keys.
+	AllowEmpty bool
+
+	mux sync.Mutex
+	// Version of the last successfully loaded source.
+	version uint64
+	loaded  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Reload loads keys from the provider and swaps them into the sink.
+//
+// Unchanged sources are skipped if provider implements KeyVersioner. Errors aren't reported to OnError callback.
+func (r *Reloader) Reload() error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	var version uint64
+	versioner, ok := r.Provider.(KeyVersioner)
+	if ok {
+		var err error
+		if version, err = versioner.KeysVersion(); err != nil {
+			return err
+		}
+		if r.loaded && version == r.version {
+			return nil
+		}
+	}
+	keys, err := r.Provider.Keys()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 && !r.AllowEmpty {
+		return ErrNoKeys
+	}
+	r.Sink.SetAll(keys)
+	r.version, r.loaded = version, true
+	return nil
+}
+
+// Start loads keys and starts background polling.
+//
+// Returns error of the initial load, polling doesn't start in that case.
+func (r *Reloader) Start() error {
+	if err := r.Reload(); err != nil {
+		return err
+	}
+	interval := r.Interval
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.stop != nil {
+		return nil
+	}
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go r.poll(interval, r.stop, r.done)
+	return nil
+}
+
+// Stop stops background polling and waits for its completion.
+func (r *Reloader) Stop() {
+	r.mux.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mux.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (r *Reloader) poll(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := r.Reload(); err != nil && r.OnError != nil {
+				r.OnError(err)
+			}
+		}
+	}
+}
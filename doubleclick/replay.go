@@ -0,0 +1,126 @@
+package doubleclick
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// Replay cache shards count (must be power of two) and shard index shift.
+	replayShards     = 64
+	replayShardShift = 58
+	// Max count of probes during slot lookup.
+	replayProbes = 8
+	// FNV-1a constants.
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// ReplayCache is a detector of replayed messages.
+//
+// Cache records hash of init vector and signature of every successfully decrypted message during TTL and reports
+// duplicates with ErrReplay. Cache has fixed size: each key may be placed only to limited count of slots and if all
+// of them are occupied by live entries, the oldest entry will be evicted. Thus, under overload replays of evicted
+// messages may pass undetected, but memory consumption never grows.
+//
+// Cache is thread-safe and should be shared between all instances that decrypt the same messages stream.
+type ReplayCache struct {
+	ttl    int64
+	now    func() time.Time
+	shards [replayShards]replayShard
+}
+
+type replayShard struct {
+	mux     sync.Mutex
+	mask    uint64
+	entries []replayEntry
+	// Padding to avoid false sharing of shard locks.
+	_ [64]byte
+}
+
+type replayEntry struct {
+	key uint64
+	// Expiration time in nanoseconds.
+	exp int64
+}
+
+// NewReplayCache makes new cache able to keep at least size entries during ttl.
+func NewReplayCache(size int, ttl time.Duration) *ReplayCache {
+	c := &ReplayCache{ttl: int64(ttl), now: time.Now}
+	n := 1
+	for n*replayShards < size || n < replayProbes {
+		n <<= 1
+	}
+	for i := 0; i < replayShards; i++ {
+		s := &c.shards[i]
+		s.mask = uint64(n - 1)
+		s.entries = make([]replayEntry, n)
+	}
+	return c
+}
+
+// SetClock replaces time source of the cache.
+//
+// Must be called before first use of the cache.
+func (c *ReplayCache) SetClock(now func() time.Time) {
+	c.now = now
+}
+
+// Check records message identified by init vector and signature and returns ErrReplay if it was already seen.
+func (c *ReplayCache) Check(initVec, sign []byte) error {
+	h := uint64(fnvOffset)
+	for i := 0; i < len(initVec); i++ {
+		h ^= uint64(initVec[i])
+		h *= fnvPrime
+	}
+	for i := 0; i < len(sign); i++ {
+		h ^= uint64(sign[i])
+		h *= fnvPrime
+	}
+	// Zero key is reserved for empty slots.
+	if h == 0 {
+		h = 1
+	}
+	now := c.now().UnixNano()
+
+	s := &c.shards[h>>replayShardShift]
+	s.mux.Lock()
+	var (
+		victim int
+		oldest = int64(math.MaxInt64)
+		free   bool
+	)
+	for i := uint64(0); i < replayProbes; i++ {
+		j := int((h + i) & s.mask)
+		e := &s.entries[j]
+		if e.exp > now {
+			if e.key == h {
+				s.mux.Unlock()
+				return ErrReplay
+			}
+			// Live entry may be evicted only if no free slots found.
+			if !free && e.exp < oldest {
+				victim, oldest = j, e.exp
+			}
+		} else if !free {
+			victim, free = j, true
+		}
+	}
+	e := &s.entries[victim]
+	e.key, e.exp = h, now+c.ttl
+	s.mux.Unlock()
+	return nil
+}
+
+// Reset removes all entries from the cache.
+func (c *ReplayCache) Reset() {
+	for i := 0; i < replayShards; i++ {
+		s := &c.shards[i]
+		s.mux.Lock()
+		for j := range s.entries {
+			s.entries[j] = replayEntry{}
+		}
+		s.mux.Unlock()
+	}
+}
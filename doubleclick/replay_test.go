@@ -0,0 +1,101 @@
+package doubleclick
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	ns int64
+}
+
+func (c *fakeClock) now() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.ns))
+}
+
+func (c *fakeClock) add(d time.Duration) {
+	atomic.AddInt64(&c.ns, int64(d))
+}
+
+func TestReplayCache(t *testing.T) {
+	t.Run("check", func(t *testing.T) {
+		clk := &fakeClock{ns: initVectorTs.UnixNano()}
+		c := NewReplayCache(1024, time.Minute)
+		c.SetClock(clk.now)
+		sign := encryptedPrice[msgLenPrice-integritySignLen:]
+		if err := c.Check(initVector, sign); err != nil {
+			t.Error(err)
+		}
+		if err := c.Check(initVector, sign); err != ErrReplay {
+			t.Error("expected error", ErrReplay)
+		}
+		clk.add(time.Minute)
+		if err := c.Check(initVector, sign); err != nil {
+			t.Error(err)
+		}
+		c.Reset()
+		if err := c.Check(initVector, sign); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("overflow", func(t *testing.T) {
+		c := NewReplayCache(1024, time.Minute)
+		var iv [initVectorLen]byte
+		for i := 0; i < 100000; i++ {
+			binary.BigEndian.PutUint64(iv[:], uint64(i))
+			if err := c.Check(iv[:], nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// The latest entries must be still detected.
+		binary.BigEndian.PutUint64(iv[:], 99999)
+		if err := c.Check(iv[:], nil); err != ErrReplay {
+			t.Error("expected error", ErrReplay)
+		}
+	})
+	t.Run("policy", func(t *testing.T) {
+		clk := &fakeClock{ns: initVectorTs.UnixNano()}
+		c := NewReplayCache(1024, time.Hour)
+		c.SetClock(clk.now)
+		d := New(TypePrice, encryptionKey, integrityKey)
+		d.SetPolicy(&Policy{MaxAge: time.Hour, Clock: clk.now, Replay: c})
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != nil {
+			t.Error(err)
+		}
+		if _, err := d.DecryptPrice(encryptedPrice, micros); err != ErrReplay {
+			t.Error("expected error", ErrReplay)
+		}
+	})
+}
+
+func BenchmarkReplayCache(b *testing.B) {
+	b.Run("check", func(b *testing.B) {
+		c := NewReplayCache(1<<20, time.Minute)
+		var iv [initVectorLen]byte
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			binary.BigEndian.PutUint64(iv[:], uint64(i))
+			if err := c.Check(iv[:], nil); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+	b.Run("check parallel", func(b *testing.B) {
+		c := NewReplayCache(1<<20, time.Minute)
+		var ctr uint64
+		b.ResetTimer()
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			var iv [initVectorLen]byte
+			for pb.Next() {
+				binary.BigEndian.PutUint64(iv[:], atomic.AddUint64(&ctr, 1))
+				if err := c.Check(iv[:], nil); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,27 @@
+package doubleclick
+
+import "math"
+
+// Rounding is a rounding mode of float price to integer micros conversion.
+type Rounding int
+
+const (
+	// RoundTrunc truncates fractional part (default mode).
+	RoundTrunc Rounding = iota
+	// RoundHalfUp rounds half away from zero.
+	RoundHalfUp
+	// RoundHalfEven rounds half to even (banker's rounding).
+	RoundHalfEven
+)
+
+// Apply rounding mode to x.
+func (r Rounding) apply(x float64) float64 {
+	switch r {
+	case RoundHalfUp:
+		return math.Round(x)
+	case RoundHalfEven:
+		return math.RoundToEven(x)
+	default:
+		return math.Trunc(x)
+	}
+}
@@ -0,0 +1,140 @@
+package doubleclick
+
+import "time"
+
+// Schedule is a set of key pairs with validity windows.
+//
+// Key pair is selected by timestamp encoded to the init vector (see AdX convention in DoubleClick doc), so no need to
+// try all key pairs during decryption. Both encryption and decryption use the same rules.
+//
+// Schedule isn't thread-safe, as well as DoubleClick.
+type Schedule struct {
+	typ  Type
+	keys []scheduleEntry
+}
+
+type scheduleEntry struct {
+	id           string
+	since, until time.Time
+	dc           *DoubleClick
+}
+
+// NewSchedule makes new empty schedule of given type.
+func NewSchedule(typ Type) *Schedule {
+	return &Schedule{typ: typ}
+}
+
+// Add registers key pair valid in range [since, until).
+//
+// Zero since or until means unbounded range from the corresponding side. In case of overlapping windows will be used
+// the first added key pair.
+func (s *Schedule) Add(key KeyPair, since, until time.Time) {
+	s.keys = append(s.keys, scheduleEntry{
+		id:    key.ID,
+		since: since,
+		until: until,
+		dc:    New(s.typ, key.EncryptionKey, key.IntegrityKey),
+	})
+}
+
+// Len returns count of key pairs in the schedule.
+func (s *Schedule) Len() int {
+	return len(s.keys)
+}
+
+// Encrypt encrypts plain to dst using key pair valid at init vector time.
+func (s *Schedule) Encrypt(dst, initVec, plain []byte) ([]byte, error) {
+	return s.EncryptFn(dst, initVec, plain, nil)
+}
+
+// EncryptFn encrypts plain to dst using key pair valid at init vector time and apply post-encryption convert func.
+func (s *Schedule) EncryptFn(dst, initVec, plain []byte, convFn ConvFn) ([]byte, error) {
+	if len(initVec) != initVectorLen {
+		return dst, ErrBadInitvLen
+	}
+	e, err := s.lookup(initVec)
+	if err != nil {
+		return dst, err
+	}
+	return e.dc.EncryptFn(dst, initVec, plain, convFn)
+}
+
+// EncryptPrice encrypts price using key pair valid at init vector time.
+func (s *Schedule) EncryptPrice(price float64, dst, initVec []byte, micros int) ([]byte, error) {
+	if len(initVec) != initVectorLen {
+		return dst, ErrBadInitvLen
+	}
+	e, err := s.lookup(initVec)
+	if err != nil {
+		return dst, err
+	}
+	// Price encryption switches type of the tool, so restore it for further calls.
+	typ := e.dc.typ
+	dst, err = e.dc.EncryptPrice(price, dst, initVec, micros)
+	e.dc.typ = typ
+	return dst, err
+}
+
+// Decrypt decrypts cipher to dst using key pair valid at init vector time.
+//
+// Returns ID of used key pair as second value.
+func (s *Schedule) Decrypt(dst, cipher []byte) ([]byte, string, error) {
+	return s.DecryptFn(dst, cipher, nil)
+}
+
+// DecryptFn decrypts cipher to dst using key pair valid at init vector time and apply post-decryption convert func.
+//
+// Returns ID of used key pair as second value.
+func (s *Schedule) DecryptFn(dst, cipher []byte, convFn ConvFn) ([]byte, string, error) {
+	if len(cipher) < initVectorLen {
+		return dst, "", ErrBadMsgLen
+	}
+	e, err := s.lookup(cipher[initVectorOffset:initVectorLen])
+	if err != nil {
+		return dst, "", err
+	}
+	if dst, err = e.dc.DecryptFn(dst, cipher, convFn); err != nil {
+		return dst, "", err
+	}
+	return dst, e.id, nil
+}
+
+// DecryptPrice decrypts price using key pair valid at init vector time.
+//
+// Returns ID of used key pair as second value.
+func (s *Schedule) DecryptPrice(cipher []byte, micros int) (float64, string, error) {
+	if len(cipher) < initVectorLen {
+		return 0, "", ErrBadMsgLen
+	}
+	e, err := s.lookup(cipher[initVectorOffset:initVectorLen])
+	if err != nil {
+		return 0, "", err
+	}
+	price, err := e.dc.DecryptPrice(cipher, micros)
+	if err != nil {
+		return 0, "", err
+	}
+	return price, e.id, nil
+}
+
+// Reset buffers of all key pairs.
+func (s *Schedule) Reset() {
+	for i := 0; i < len(s.keys); i++ {
+		s.keys[i].dc.Reset()
+	}
+}
+
+// Find key pair valid at init vector time.
+func (s *Schedule) lookup(initVec []byte) (*scheduleEntry, error) {
+	if len(s.keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	t := initVectorTime(initVec)
+	for i := 0; i < len(s.keys); i++ {
+		e := &s.keys[i]
+		if (e.since.IsZero() || !t.Before(e.since)) && (e.until.IsZero() || t.Before(e.until)) {
+			return e, nil
+		}
+	}
+	return nil, ErrNoKeyForTime
+}
@@ -0,0 +1,111 @@
+package doubleclick
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	// Init vector time is 2000-01-01 17:34:56.789 UTC.
+	var (
+		t0 = time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+		t1 = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		t2 = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	t.Run("init vector time", func(t *testing.T) {
+		it := initVectorTime(initVector)
+		if !it.Equal(time.Date(2000, 1, 1, 17, 34, 56, 789000000, time.UTC)) {
+			t.Error("init vector time mismatch, got", it.UTC())
+		}
+	})
+	t.Run("decrypt", func(t *testing.T) {
+		s := NewSchedule(TypeAdID)
+		s.Add(keyPairNew, t0, t1)
+		s.Add(keyPairOld, t1, t2)
+		dst, id, err := s.Decrypt(nil, encryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if id != "old" {
+			t.Error("key ID mismatch, got", id)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		s := NewSchedule(TypeAdID)
+		s.Add(keyPairNew, t2, time.Time{})
+		s.Add(keyPairOld, time.Time{}, t2)
+		dst, err := s.Encrypt(nil, initVector, decryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedAdID) {
+			t.Error("encrypt AdID failed")
+		}
+	})
+	t.Run("price", func(t *testing.T) {
+		s := NewSchedule(TypePrice)
+		s.Add(keyPairOld, t1, time.Time{})
+		price, id, err := s.DecryptPrice(encryptedPrice, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if id != "old" {
+			t.Error("key ID mismatch, got", id)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+	})
+	t.Run("encrypt price", func(t *testing.T) {
+		s := NewSchedule(TypeAdID)
+		s.Add(keyPairOld, t1, t2)
+		if _, err := s.EncryptPrice(decryptedPrice, nil, initVector, micros); err != nil {
+			t.Error(err)
+		}
+		// Type of the schedule must survive price encryption.
+		if _, _, err := s.Decrypt(nil, encryptedAdID); err != nil {
+			t.Error("decrypt AdID after price encryption failed:", err)
+		}
+	})
+	t.Run("out of windows", func(t *testing.T) {
+		s := NewSchedule(TypeAdID)
+		s.Add(keyPairOld, t0, t1)
+		s.Add(keyPairNew, t2, time.Time{})
+		if _, _, err := s.Decrypt(nil, encryptedAdID); err != ErrNoKeyForTime {
+			t.Error("expected error", ErrNoKeyForTime)
+		}
+		if _, err := s.Encrypt(nil, initVector, decryptedAdID); err != ErrNoKeyForTime {
+			t.Error("expected error", ErrNoKeyForTime)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		s := NewSchedule(TypeAdID)
+		if _, _, err := s.Decrypt(nil, encryptedAdID); err != ErrNoKeys {
+			t.Error("expected error", ErrNoKeys)
+		}
+	})
+}
+
+func BenchmarkSchedule(b *testing.B) {
+	b.Run("decrypt", func(b *testing.B) {
+		s := NewSchedule(TypePrice)
+		s.Add(keyPairNew, time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+		s.Add(keyPairOld, time.Time{}, time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			price, _, err := s.DecryptPrice(encryptedPrice, micros)
+			if err != nil {
+				b.Error(err)
+			}
+			if price != decryptedPrice {
+				b.Error("decrypt price failed")
+			}
+			s.Reset()
+		}
+	})
+}
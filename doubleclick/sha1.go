@@ -0,0 +1,261 @@
+package doubleclick
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash"
+	"math/bits"
+	"sync"
+)
+
+const (
+	// SHA-1 bounds.
+	sha1BlockLen = 64
+	sha1Len      = 20
+	// Max length of message that fits to one block together with padding.
+	sha1MaxShortLen = sha1BlockLen - 9
+	// HMAC pads.
+	hmacIpad = 0x36
+	hmacOpad = 0x5c
+	// SHA-1 round constants.
+	sha1K0 = 0x5A827999
+	sha1K1 = 0x6ED9EBA1
+	sha1K2 = 0x8F1BBCDC
+	sha1K3 = 0xCA62C1D6
+)
+
+var (
+	// SHA-1 initial state.
+	sha1Init = [5]uint32{0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476, 0xC3D2E1F0}
+	// Template of outer hash block: inner digest placeholder || padding || length of opad block and inner digest.
+	sha1OuterBlock = [sha1BlockLen]byte{sha1Len: 0x80, 62: (sha1BlockLen + sha1Len) << 3 >> 8, 63: (sha1BlockLen + sha1Len) << 3 & 0xff}
+)
+
+// HMAC-SHA1 key schedule.
+//
+// Keeps SHA-1 midstates after absorbing of ipad and opad blocks, so each HMAC computation needs only compression of
+// message blocks and one block of outer hash. Short messages (up to 55 bytes, i.e. all fixed types) take exactly two
+// compressions.
+//
+// Midstates are used by SHA-NI code (see hmacFinal) and by multi-buffer lanes. On CPUs without SHA extensions scalar HMAC is computed
+// by crypto/hmac, since stdlib SHA-1 assembly is faster than pure Go compression.
+type hmacKey struct {
+	ipad, opad [5]uint32
+	// Pool of stdlib HMAC states, nil if SHA extensions are available.
+	std *sync.Pool
+	// State owned by single instance, see owned().
+	own *stdHMAC
+}
+
+// Stdlib HMAC state.
+type stdHMAC struct {
+	h hash.Hash
+	// Message buffer, keeps caller buffers from escaping to heap.
+	msg []byte
+	out [sha1Len]byte
+}
+
+// Make HMAC key schedule.
+func newHMACKey(key []byte) (k hmacKey) {
+	// Long keys must be hashed first.
+	if len(key) > sha1BlockLen {
+		h := sha1.Sum(key)
+		key = h[:]
+	}
+	var ipad, opad [sha1BlockLen]byte
+	copy(ipad[:], key)
+	copy(opad[:], key)
+	for i := 0; i < sha1BlockLen; i++ {
+		ipad[i] ^= hmacIpad
+		opad[i] ^= hmacOpad
+	}
+	k.ipad, k.opad = sha1Init, sha1Init
+	sha1Block(&k.ipad, &ipad)
+	sha1Block(&k.opad, &opad)
+	if !useSHANI {
+		key := append([]byte(nil), key...)
+		k.std = &sync.Pool{New: func() interface{} {
+			return &stdHMAC{h: hmac.New(sha1.New, key)}
+		}}
+	}
+	return
+}
+
+// Make copy of key schedule with own stdlib HMAC state.
+//
+// Owned copy skips the pool of states, but must not be used concurrently.
+func (k hmacKey) owned() hmacKey {
+	if k.std != nil {
+		k.own = k.std.New().(*stdHMAC)
+	}
+	return k
+}
+
+// Make copy of key schedule suitable for concurrent use.
+func (k hmacKey) shared() hmacKey {
+	k.own = nil
+	return k
+}
+
+// Compute HMAC of concatenation of a and b to out.
+func (k *hmacKey) sumTo(out *[sha1Len]byte, a, b []byte) {
+	if k.std != nil {
+		k.sumStd(out, a, b)
+		return
+	}
+	var blk [sha1BlockLen]byte
+	if len(a)+len(b) <= sha1MaxShortLen {
+		// Fast path: message and padding fit to one block.
+		sha1Short(&blk, a, b)
+		hmacFinal(&k.ipad, &k.opad, &blk, out)
+		return
+	}
+	inner := k.ipad
+	sha1Long(&inner, &blk, a, b)
+	hmacFinal(&inner, &k.opad, &blk, out)
+}
+
+// Compute HMAC of concatenation a1||b1 using key k1 and HMAC of concatenation a2||b2 using key k2.
+//
+// Computations are independent, so on CPUs with SHA extensions short messages take nearly the time of one HMAC.
+func sumPair(k1 *hmacKey, out1 *[sha1Len]byte, a1, b1 []byte, k2 *hmacKey, out2 *[sha1Len]byte, a2, b2 []byte) {
+	if k1.std != nil || k2.std != nil || len(a1)+len(b1) > sha1MaxShortLen || len(a2)+len(b2) > sha1MaxShortLen {
+		k1.sumTo(out1, a1, b1)
+		k2.sumTo(out2, a2, b2)
+		return
+	}
+	var blk1, blk2 [sha1BlockLen]byte
+	sha1Short(&blk1, a1, b1)
+	sha1Short(&blk2, a2, b2)
+	hmacFinal2(&k1.ipad, &k1.opad, &blk1, out1, &k2.ipad, &k2.opad, &blk2, out2)
+}
+
+// Compute HMAC using stdlib.
+func (k *hmacKey) sumStd(out *[sha1Len]byte, a, b []byte) {
+	s := k.own
+	if s == nil {
+		s = k.std.Get().(*stdHMAC)
+		defer k.std.Put(s)
+	}
+	s.msg = append(append(s.msg[:0], a...), b...)
+	s.h.Reset()
+	_, _ = s.h.Write(s.msg)
+	copy(out[:], s.h.Sum(s.out[:0]))
+}
+
+// Compress the last block of HMAC message to the copy of inner state h and compute outer hash using opad state.
+func hmacFinalGeneric(h, opad *[5]uint32, p *[sha1BlockLen]byte, out *[sha1Len]byte) {
+	inner := *h
+	sha1Block(&inner, p)
+	// Outer hash always takes one block.
+	blk := sha1OuterBlock
+	for i := 0; i < 5; i++ {
+		binary.BigEndian.PutUint32(blk[i*4:], inner[i])
+	}
+	outer := *opad
+	sha1Block(&outer, &blk)
+	for i := 0; i < 5; i++ {
+		binary.BigEndian.PutUint32(out[i*4:], outer[i])
+	}
+}
+
+// Fill block by short message (concatenation of a and b) and padding.
+func sha1Short(blk *[sha1BlockLen]byte, a, b []byte) {
+	n := copy(blk[:], a)
+	n += copy(blk[n:], b)
+	blk[n] = 0x80
+	binary.BigEndian.PutUint64(blk[sha1BlockLen-8:], uint64(sha1BlockLen+n)<<3)
+}
+
+// Compress long message (concatenation of a and b) to state h using blk as a scratch.
+//
+// The last block with padding is left in blk uncompressed, see hmacFinal.
+func sha1Long(h *[5]uint32, blk *[sha1BlockLen]byte, a, b []byte) {
+	var (
+		nx    int
+		total = uint64(sha1BlockLen + len(a) + len(b))
+	)
+	write := func(p []byte) {
+		for len(p) > 0 {
+			c := copy(blk[nx:], p)
+			nx += c
+			p = p[c:]
+			if nx == sha1BlockLen {
+				sha1Block(h, blk)
+				nx = 0
+			}
+		}
+	}
+	write(a)
+	write(b)
+	// Padding.
+	blk[nx] = 0x80
+	nx++
+	if nx > sha1BlockLen-8 {
+		for i := nx; i < sha1BlockLen; i++ {
+			blk[i] = 0
+		}
+		sha1Block(h, blk)
+		nx = 0
+	}
+	for i := nx; i < sha1BlockLen-8; i++ {
+		blk[i] = 0
+	}
+	binary.BigEndian.PutUint64(blk[sha1BlockLen-8:], total<<3)
+}
+
+// Pure Go SHA-1 compression function.
+func sha1BlockGeneric(h *[5]uint32, p *[sha1BlockLen]byte) {
+	var w [16]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(p[i*4:])
+	}
+	sha1Words(h, &w)
+}
+
+// Compress block given as big-endian words to state h.
+//
+// Words are used as a message schedule, so w is overwritten.
+func sha1Words(h *[5]uint32, w *[16]uint32) {
+	a, b, c, d, e := h[0], h[1], h[2], h[3], h[4]
+	i := 0
+	for ; i < 16; i++ {
+		f := b&c | (^b)&d
+		t := bits.RotateLeft32(a, 5) + f + e + w[i&0xf] + sha1K0
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+	for ; i < 20; i++ {
+		tmp := w[(i-3)&0xf] ^ w[(i-8)&0xf] ^ w[(i-14)&0xf] ^ w[i&0xf]
+		w[i&0xf] = bits.RotateLeft32(tmp, 1)
+		f := b&c | (^b)&d
+		t := bits.RotateLeft32(a, 5) + f + e + w[i&0xf] + sha1K0
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+	for ; i < 40; i++ {
+		tmp := w[(i-3)&0xf] ^ w[(i-8)&0xf] ^ w[(i-14)&0xf] ^ w[i&0xf]
+		w[i&0xf] = bits.RotateLeft32(tmp, 1)
+		f := b ^ c ^ d
+		t := bits.RotateLeft32(a, 5) + f + e + w[i&0xf] + sha1K1
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+	for ; i < 60; i++ {
+		tmp := w[(i-3)&0xf] ^ w[(i-8)&0xf] ^ w[(i-14)&0xf] ^ w[i&0xf]
+		w[i&0xf] = bits.RotateLeft32(tmp, 1)
+		f := ((b | c) & d) | (b & c)
+		t := bits.RotateLeft32(a, 5) + f + e + w[i&0xf] + sha1K2
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+	for ; i < 80; i++ {
+		tmp := w[(i-3)&0xf] ^ w[(i-8)&0xf] ^ w[(i-14)&0xf] ^ w[i&0xf]
+		w[i&0xf] = bits.RotateLeft32(tmp, 1)
+		f := b ^ c ^ d
+		t := bits.RotateLeft32(a, 5) + f + e + w[i&0xf] + sha1K3
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+	h[0] += a
+	h[1] += b
+	h[2] += c
+	h[3] += d
+	h[4] += e
+}
@@ -0,0 +1,105 @@
+package doubleclick
+
+//go:generate go run sha1_gen.go
+
+// CPU supports SHA extensions (and SSSE3/SSE4.1 required by SHA-NI code).
+var useSHANI = hasSHANI()
+
+func hasSHANI() bool {
+	_, _, ecx1, _ := cpuid(1, 0)
+	_, ebx7, _, _ := cpuid(7, 0)
+	const (
+		ssse3  = 1 << 9
+		sse41  = 1 << 19
+		shaExt = 1 << 29
+	)
+	return ecx1&ssse3 != 0 && ecx1&sse41 != 0 && ebx7&shaExt != 0
+}
+
+// CPU and OS support AVX2 (required by multi-buffer SHA-1 code).
+var useAVX2 = hasAVX2()
+
+func hasAVX2() bool {
+	_, _, ecx1, _ := cpuid(1, 0)
+	const (
+		osxsave = 1 << 27
+		avx     = 1 << 28
+		avx2    = 1 << 5
+		// XCR0 bits of SSE and AVX states.
+		xmmYmm = 1<<1 | 1<<2
+	)
+	if ecx1&osxsave == 0 || ecx1&avx == 0 {
+		return false
+	}
+	// Check that OS saves YMM registers on context switch.
+	if eax, _ := xgetbv(); eax&xmmYmm != xmmYmm {
+		return false
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	return ebx7&avx2 != 0
+}
+
+// Execute CPUID instruction.
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+// Execute XGETBV instruction (read XCR0 register).
+func xgetbv() (eax, edx uint32)
+
+// SHA-1 compression function using SHA extensions.
+//
+//go:noescape
+func sha1BlockSHANI(h *[5]uint32, p *[sha1BlockLen]byte)
+
+// Last block of HMAC-SHA1 using SHA extensions.
+//
+//go:noescape
+func hmacFinalSHANI(h, opad *[5]uint32, p *[sha1BlockLen]byte, out *[sha1Len]byte)
+
+// Last blocks of two independent HMAC-SHA1 computations using SHA extensions.
+//
+//go:noescape
+func hmacFinal2SHANI(h1, opad1 *[5]uint32, p1 *[sha1BlockLen]byte, out1 *[sha1Len]byte,
+	h2, opad2 *[5]uint32, p2 *[sha1BlockLen]byte, out2 *[sha1Len]byte)
+
+// Last block of HMAC-SHA1.
+func hmacFinal(h, opad *[5]uint32, p *[sha1BlockLen]byte, out *[sha1Len]byte) {
+	if useSHANI {
+		hmacFinalSHANI(h, opad, p, out)
+		return
+	}
+	hmacFinalGeneric(h, opad, p, out)
+}
+
+// Last blocks of two independent HMAC-SHA1 computations.
+func hmacFinal2(h1, opad1 *[5]uint32, p1 *[sha1BlockLen]byte, out1 *[sha1Len]byte,
+	h2, opad2 *[5]uint32, p2 *[sha1BlockLen]byte, out2 *[sha1Len]byte) {
+	if useSHANI {
+		hmacFinal2SHANI(h1, opad1, p1, out1, h2, opad2, p2, out2)
+		return
+	}
+	hmacFinalGeneric(h1, opad1, p1, out1)
+	hmacFinalGeneric(h2, opad2, p2, out2)
+}
+
+// SHA-1 compression function.
+func sha1Block(h *[5]uint32, p *[sha1BlockLen]byte) {
+	if useSHANI {
+		sha1BlockSHANI(h, p)
+		return
+	}
+	sha1BlockGeneric(h, p)
+}
+
+// Multi-buffer SHA-1 compression function using AVX2.
+//
+//go:noescape
+func sha1Block8AVX2(h *[5][lanes]uint32, w *[16][lanes]uint32)
+
+// Multi-buffer SHA-1 compression function.
+func sha1Block8(h *[5][lanes]uint32, w *[16][lanes]uint32) {
+	if useAVX2 {
+		sha1Block8AVX2(h, w)
+		return
+	}
+	sha1Block8Generic(h, w)
+}
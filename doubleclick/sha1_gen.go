@@ -0,0 +1,407 @@
+//go:build ignore
+// +build ignore
+
+// This program generates sha1_amd64.s. Invoke it as
+//
+//	go run sha1_gen.go [-out sha1_amd64.s]
+//
+// SHA-1 rounds are fully unrolled, so the generator keeps register allocation and message schedule rotation in one
+// place instead of 80 hand-written copies of them.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+var out = flag.String("out", "sha1_amd64.s", "output file")
+
+var buf bytes.Buffer
+
+// Write line of assembly.
+func p(format string, args ...interface{}) {
+	fmt.Fprintf(&buf, format, args...)
+	buf.WriteByte('\n')
+}
+
+// Write instruction.
+func i(format string, args ...interface{}) {
+	p("\t"+format, args...)
+}
+
+func main() {
+	flag.Parse()
+	p("// Code generated by go run sha1_gen.go. DO NOT EDIT.")
+	p("")
+	p(`#include "textflag.h"`)
+	p("")
+	cpuid()
+	shani()
+	avx2()
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cpuid() {
+	p("// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)")
+	p("TEXT ·cpuid(SB), NOSPLIT, $0-24")
+	i("MOVL eaxArg+0(FP), AX")
+	i("MOVL ecxArg+4(FP), CX")
+	i("CPUID")
+	i("MOVL AX, eax+8(FP)")
+	i("MOVL BX, ebx+12(FP)")
+	i("MOVL CX, ecx+16(FP)")
+	i("MOVL DX, edx+20(FP)")
+	i("RET")
+	p("")
+	p("// func xgetbv() (eax, edx uint32)")
+	p("TEXT ·xgetbv(SB), NOSPLIT, $0-8")
+	i("MOVL $0, CX")
+	i("XGETBV")
+	i("MOVL AX, eax+0(FP)")
+	i("MOVL DX, edx+4(FP)")
+	i("RET")
+	p("")
+}
+
+// Registers of SHA-NI code.
+const (
+	abcd = "X0"
+	e0   = "X1"
+	e1   = "X2"
+	mask = "X7"
+)
+
+// Message schedule registers of SHA-NI code.
+var msg = [4]string{"X3", "X4", "X5", "X6"}
+
+func shani() {
+	p("// Byte order flip mask.")
+	p("DATA flipMask<>+0(SB)/8, $0x08090a0b0c0d0e0f")
+	p("DATA flipMask<>+8(SB)/8, $0x0001020304050607")
+	p("GLOBL flipMask<>(SB), RODATA, $16")
+	p("")
+	p("// Words 4-7 of outer HMAC block without inner digest word: W5 is a padding.")
+	p("DATA outerW4<>+0(SB)/8, $0")
+	p("DATA outerW4<>+8(SB)/8, $0x80000000")
+	p("GLOBL outerW4<>(SB), RODATA, $16")
+	p("")
+	p("// Words 12-15 of outer HMAC block: W15 is a length of opad block and inner digest in bits.")
+	p("DATA outerW12<>+0(SB)/8, $0x2a0")
+	p("DATA outerW12<>+8(SB)/8, $0")
+	p("GLOBL outerW12<>(SB), RODATA, $16")
+	p("")
+
+	p("// func sha1BlockSHANI(h *[5]uint32, p *[64]byte)")
+	p("//")
+	p("// Registers: X0 - ABCD, X1/X2 - E0/E1, X3..X6 - message schedule, X7 - flip mask, X8 - scratch.")
+	p("TEXT ·sha1BlockSHANI(SB), NOSPLIT, $0-16")
+	i("MOVQ h+0(FP), DI")
+	i("MOVQ p+8(FP), SI")
+	i("MOVOU flipMask<>(SB), %s", mask)
+	p("")
+	s := shaniStream{abcd: abcd, e0: e0, e1: e1, msg: msg, tmp: "X8", h: "DI", p: "SI"}
+	var c code
+	s.block(&c)
+	c.flush()
+	p("")
+	i("// Store state.")
+	i("PSHUFD $0x1b, %s, %s", abcd, abcd)
+	i("MOVOU %s, 0(DI)", abcd)
+	i("PEXTRD $3, %s, 16(DI)", e0)
+	i("RET")
+	p("")
+
+	p("// func hmacFinalSHANI(h, opad *[5]uint32, p *[64]byte, out *[20]byte)")
+	p("//")
+	p("// Compresses the last block of HMAC message (p contains message tail and SHA-1 padding) to the copy of inner state")
+	p("// h and computes outer hash. Inner digest passes to the outer hash in registers: ABCD and E registers hold digest")
+	p("// words in the same lanes as message schedule needs.")
+	p("TEXT ·hmacFinalSHANI(SB), NOSPLIT, $0-32")
+	s = shaniStream{abcd: abcd, e0: e0, e1: e1, msg: msg, tmp: "X8", h: "DI", opad: "BX", p: "SI", out: "DX"}
+	i("MOVQ h+0(FP), DI")
+	i("MOVQ opad+8(FP), BX")
+	i("MOVQ p+16(FP), SI")
+	i("MOVQ out+24(FP), DX")
+	i("MOVOU flipMask<>(SB), %s", mask)
+	p("")
+	c = nil
+	s.hmac(&c)
+	c.flush()
+	p("")
+	s.store(mask, "AX")
+	i("RET")
+	p("")
+
+	p("// func hmacFinal2SHANI(h1, opad1 *[5]uint32, p1 *[64]byte, out1 *[20]byte, h2, opad2 *[5]uint32, p2 *[64]byte,")
+	p("//	out2 *[20]byte)")
+	p("//")
+	p("// Computes two independent HMACs like hmacFinalSHANI does. Instructions of both computations are interleaved, so")
+	p("// latency of SHA1RNDS4 chain of one computation is hidden by another one. The second computation uses X8..X14 and")
+	p("// takes flip mask register as a scratch after message loads, so mask is reloaded before stores.")
+	p("TEXT ·hmacFinal2SHANI(SB), NOSPLIT, $0-64")
+	s1 := shaniStream{abcd: abcd, e0: e0, e1: e1, msg: msg, tmp: "X15", h: "DI", opad: "BX", p: "SI", out: "DX"}
+	s2 := shaniStream{abcd: "X8", e0: "X9", e1: "X10", msg: [4]string{"X11", "X12", "X13", "X14"}, tmp: mask,
+		h: "R8", opad: "R9", p: "R10", out: "R11"}
+	i("MOVQ h1+0(FP), DI")
+	i("MOVQ opad1+8(FP), BX")
+	i("MOVQ p1+16(FP), SI")
+	i("MOVQ out1+24(FP), DX")
+	i("MOVQ h2+32(FP), R8")
+	i("MOVQ opad2+40(FP), R9")
+	i("MOVQ p2+48(FP), R10")
+	i("MOVQ out2+56(FP), R11")
+	i("MOVOU flipMask<>(SB), %s", mask)
+	p("")
+	var c1, c2 code
+	s1.hmac(&c1)
+	s2.hmac(&c2)
+	interleave(c1, c2).flush()
+	p("")
+	i("MOVOU flipMask<>(SB), X15")
+	s1.store("X15", "AX")
+	s2.store("X15", "CX")
+	i("RET")
+	p("")
+}
+
+// Lines of assembly code.
+type code []string
+
+// Add instruction.
+func (c *code) i(format string, args ...interface{}) {
+	*c = append(*c, "\t"+fmt.Sprintf(format, args...))
+}
+
+// Add comment.
+func (c *code) comment(format string, args ...interface{}) {
+	c.i("// "+format, args...)
+}
+
+// Add empty line.
+func (c *code) blank() {
+	*c = append(*c, "")
+}
+
+// Write code to the output.
+func (c code) flush() {
+	for _, l := range c {
+		p("%s", l)
+	}
+}
+
+// Interleave instructions of two codes of the same structure. Comments and empty lines are taken from a only.
+func interleave(a, b code) (c code) {
+	if len(a) != len(b) {
+		log.Fatal("interleaved codes have different length")
+	}
+	for j := range a {
+		c = append(c, a[j])
+		if l := strings.TrimSpace(b[j]); l != "" && !strings.HasPrefix(l, "//") {
+			c = append(c, b[j])
+		}
+	}
+	return
+}
+
+// Registers of one SHA-NI computation.
+type shaniStream struct {
+	abcd, e0, e1 string
+	msg          [4]string
+	// Scratch register.
+	tmp string
+	// Pointers to inner state, outer state, message block and output.
+	h, opad, p, out string
+}
+
+// Compress message block to state h. State itself isn't updated, the result stays in ABCD and E registers.
+func (s shaniStream) block(c *code) {
+	c.comment("Load state.")
+	s.load(c, s.h)
+	c.blank()
+	s.rounds(c, true)
+	c.blank()
+	s.add(c, s.h)
+}
+
+// Compress the last block of inner hash and compute outer hash.
+func (s shaniStream) hmac(c *code) {
+	s.block(c)
+	c.blank()
+	c.comment("Build outer block: W0-W3 = ABCD, W4 = E, W5 = padding, W15 = length.")
+	c.i("MOVO %s, %s", s.abcd, s.msg[0])
+	c.i("MOVO %s, %s", s.e0, s.msg[1])
+	c.i("MOVOU outerW4<>(SB), %s", s.tmp)
+	c.i("POR %s, %s", s.tmp, s.msg[1])
+	c.i("PXOR %s, %s", s.msg[2], s.msg[2])
+	c.i("MOVOU outerW12<>(SB), %s", s.msg[3])
+	c.blank()
+	c.comment("Load outer state (opad).")
+	s.load(c, s.opad)
+	c.blank()
+	s.rounds(c, false)
+	c.blank()
+	s.add(c, s.opad)
+}
+
+// Load state from memory at ptr.
+func (s shaniStream) load(c *code, ptr string) {
+	c.i("MOVOU 0(%s), %s", ptr, s.abcd)
+	c.i("PXOR %s, %s", s.e0, s.e0)
+	c.i("PINSRD $3, 16(%s), %s", ptr, s.e0)
+	c.i("PSHUFD $0x1b, %s, %s", s.abcd, s.abcd)
+}
+
+// Add state from memory at ptr (the state compression started from).
+func (s shaniStream) add(c *code, ptr string) {
+	c.comment("Add initial state.")
+	c.i("PXOR %s, %s", s.tmp, s.tmp)
+	c.i("PINSRD $3, 16(%s), %s", ptr, s.tmp)
+	c.i("SHA1NEXTE %s, %s", s.tmp, s.e0)
+	c.i("MOVOU 0(%s), %s", ptr, s.tmp)
+	c.i("PSHUFD $0x1b, %s, %s", s.tmp, s.tmp)
+	c.i("PADDD %s, %s", s.tmp, s.abcd)
+}
+
+// Write digest to the output in big-endian order using general purpose register r as a scratch.
+func (s shaniStream) store(mask, r string) {
+	i("// Store digest in big-endian order.")
+	i("PSHUFB %s, %s", mask, s.abcd)
+	i("MOVOU %s, 0(%s)", s.abcd, s.out)
+	i("PEXTRD $3, %s, %s", s.e0, r)
+	i("BSWAPL %s", r)
+	i("MOVL %s, 16(%s)", r, s.out)
+}
+
+// Emit 80 rounds, 4 rounds per SHA1RNDS4. If loads is false, message words are already in registers.
+func (s shaniStream) rounds(c *code, loads bool) {
+	m := func(j int) string { return s.msg[j%4] }
+	for r := 0; r < 20; r++ {
+		c.comment("Rounds %d-%d", 4*r, 4*r+3)
+		if r == 0 {
+			if loads {
+				c.i("MOVOU 0(%s), %s", s.p, m(0))
+				c.i("PSHUFB %s, %s", mask, m(0))
+			}
+			c.i("PADDD %s, %s", m(0), s.e0)
+			c.i("MOVO %s, %s", s.abcd, s.e1)
+			c.i("SHA1RNDS4 $0, %s, %s", s.e0, s.abcd)
+			continue
+		}
+		ea, eb := s.e0, s.e1
+		if r%2 == 1 {
+			ea, eb = s.e1, s.e0
+		}
+		if r <= 3 && loads {
+			c.i("MOVOU %d(%s), %s", 16*r, s.p, m(r))
+			c.i("PSHUFB %s, %s", mask, m(r))
+		}
+		c.i("SHA1NEXTE %s, %s", m(r), ea)
+		c.i("MOVO %s, %s", s.abcd, eb)
+		if r >= 3 && r <= 18 {
+			c.i("SHA1MSG2 %s, %s", m(r), m(r+1))
+		}
+		c.i("SHA1RNDS4 $%d, %s, %s", r/5, ea, s.abcd)
+		if r >= 1 && r <= 16 {
+			c.i("SHA1MSG1 %s, %s", m(r), m(r+3))
+		}
+		if r >= 2 && r <= 17 {
+			c.i("PXOR %s, %s", m(r), m(r+2))
+		}
+	}
+}
+
+func avx2() {
+	p("// SHA-1 round constants.")
+	for j, k := range [...]uint32{0x5a827999, 0x6ed9eba1, 0x8f1bbcdc, 0xca62c1d6} {
+		p("DATA sha1K%dx8<>+0(SB)/4, $%#x", j, k)
+		p("GLOBL sha1K%dx8<>(SB), RODATA, $4", j)
+	}
+	p("")
+	p("// func sha1Block8AVX2(h *[5][8]uint32, w *[16][8]uint32)")
+	p("//")
+	p("// Compresses 8 independent blocks at once, one block per 32-bit lane. Message schedule is kept in-place in w.")
+	p("// Registers: Y0..Y4 - working variables (renamed every round), Y5 - round constant, Y6/Y7 - temporaries,")
+	p("// Y8/Y9 - message word, Y10..Y14 - saved state.")
+	p("TEXT ·sha1Block8AVX2(SB), NOSPLIT, $0-16")
+	i("MOVQ h+0(FP), SI")
+	i("MOVQ w+8(FP), DI")
+	p("")
+	i("// Load state.")
+	for j := 0; j < 5; j++ {
+		i("VMOVDQU %d(SI), Y%d", j*32, j)
+	}
+	for j := 0; j < 5; j++ {
+		i("VMOVDQA Y%d, Y%d", j, j+10)
+	}
+
+	regs := []string{"Y0", "Y1", "Y2", "Y3", "Y4"}
+	for t := 0; t < 80; t++ {
+		a, b, c, d, e := regs[0], regs[1], regs[2], regs[3], regs[4]
+		if t%20 == 0 {
+			p("")
+			i("// Rounds %d-%d.", t, t+19)
+			i("VPBROADCASTD sha1K%dx8<>(SB), Y5", t/20)
+		}
+		// Message word.
+		if t < 16 {
+			i("VMOVDQU %d(DI), Y8", t*32)
+		} else {
+			i("VMOVDQU %d(DI), Y8", ((t-3)%16)*32)
+			i("VPXOR %d(DI), Y8, Y8", ((t-8)%16)*32)
+			i("VPXOR %d(DI), Y8, Y8", ((t-14)%16)*32)
+			i("VPXOR %d(DI), Y8, Y8", (t%16)*32)
+			i("VPSLLD $1, Y8, Y9")
+			i("VPSRLD $31, Y8, Y8")
+			i("VPOR Y9, Y8, Y8")
+			i("VMOVDQU Y8, %d(DI)", (t%16)*32)
+		}
+		i("VPADDD Y8, %s, %s", e, e)
+		i("VPADDD Y5, %s, %s", e, e)
+		// Round function.
+		switch {
+		case t < 20:
+			i("VPXOR %s, %s, Y6", c, d)
+			i("VPAND %s, Y6, Y6", b)
+			i("VPXOR %s, Y6, Y6", d)
+		case t >= 40 && t < 60:
+			i("VPAND %s, %s, Y6", b, c)
+			i("VPOR %s, %s, Y7", b, c)
+			i("VPAND %s, Y7, Y7", d)
+			i("VPOR Y7, Y6, Y6")
+		default:
+			i("VPXOR %s, %s, Y6", b, c)
+			i("VPXOR %s, Y6, Y6", d)
+		}
+		i("VPADDD Y6, %s, %s", e, e)
+		// e += rotl(a, 5)
+		i("VPSLLD $5, %s, Y6", a)
+		i("VPSRLD $27, %s, Y7", a)
+		i("VPOR Y7, Y6, Y6")
+		i("VPADDD Y6, %s, %s", e, e)
+		// b = rotl(b, 30)
+		i("VPSLLD $30, %s, Y6", b)
+		i("VPSRLD $2, %s, %s", b, b)
+		i("VPOR Y6, %s, %s", b, b)
+		regs = []string{e, a, b, c, d}
+	}
+	// 80 rounds rotate registers back to the original order.
+	if strings.Join(regs, ",") != "Y0,Y1,Y2,Y3,Y4" {
+		log.Fatal("unexpected register order after 80 rounds")
+	}
+	p("")
+	i("// Add saved state and store.")
+	for j := 0; j < 5; j++ {
+		i("VPADDD Y%d, Y%d, Y%d", j+10, j, j)
+	}
+	for j := 0; j < 5; j++ {
+		i("VMOVDQU Y%d, %d(SI)", j, j*32)
+	}
+	i("VZEROUPPER")
+	i("RET")
+}
@@ -0,0 +1,29 @@
+//go:build !amd64
+// +build !amd64
+
+package doubleclick
+
+// No SIMD implementations on this platform.
+var useSHANI, useAVX2 = false, false
+
+// SHA-1 compression function.
+func sha1Block(h *[5]uint32, p *[sha1BlockLen]byte) {
+	sha1BlockGeneric(h, p)
+}
+
+// Last block of HMAC-SHA1.
+func hmacFinal(h, opad *[5]uint32, p *[sha1BlockLen]byte, out *[sha1Len]byte) {
+	hmacFinalGeneric(h, opad, p, out)
+}
+
+// Last blocks of two independent HMAC-SHA1 computations.
+func hmacFinal2(h1, opad1 *[5]uint32, p1 *[sha1BlockLen]byte, out1 *[sha1Len]byte,
+	h2, opad2 *[5]uint32, p2 *[sha1BlockLen]byte, out2 *[sha1Len]byte) {
+	hmacFinalGeneric(h1, opad1, p1, out1)
+	hmacFinalGeneric(h2, opad2, p2, out2)
+}
+
+// Multi-buffer SHA-1 compression function.
+func sha1Block8(h *[5][lanes]uint32, w *[16][lanes]uint32) {
+	sha1Block8Generic(h, w)
+}
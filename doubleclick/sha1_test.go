@@ -0,0 +1,76 @@
+package doubleclick
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestHMACKey(t *testing.T) {
+	check := func(t *testing.T, mk func(key []byte) hmacKey) {
+		keys := [][]byte{encryptionKey, integrityKey, nil, bytes.Repeat([]byte{0xaa}, 64), bytes.Repeat([]byte{0xbb}, 100)}
+		msg := bytes.Repeat([]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd}, 40)
+		for _, key := range keys {
+			k := mk(key)
+			h := hmac.New(sha1.New, key)
+			for n := 0; n < len(msg); n += 3 {
+				h.Reset()
+				h.Write(msg[:n])
+				h.Write(initVector)
+				expect := h.Sum(nil)
+				var got [sha1Len]byte
+				if k.sumTo(&got, msg[:n], initVector); !bytes.Equal(got[:], expect) {
+					t.Errorf("HMAC mismatch: key len %d, msg len %d", len(key), n)
+				}
+				// Pair of HMACs computed at once.
+				var pad, sign [sha1Len]byte
+				sumPair(&k, &pad, initVector, nil, &k, &sign, msg[:n], initVector)
+				h.Reset()
+				h.Write(initVector)
+				if !bytes.Equal(sign[:], expect) || !bytes.Equal(pad[:], h.Sum(nil)) {
+					t.Errorf("HMAC pair mismatch: key len %d, msg len %d", len(key), n)
+				}
+			}
+		}
+	}
+	t.Run("native", func(t *testing.T) { check(t, newHMACKey) })
+	t.Run("owned", func(t *testing.T) {
+		check(t, func(key []byte) hmacKey { return newHMACKey(key).owned() })
+	})
+	if useSHANI {
+		defer func() { useSHANI = true }()
+		t.Run("generic", func(t *testing.T) {
+			// Key schedule built for SHA extensions, but hashed by pure Go compression.
+			check(t, func(key []byte) hmacKey {
+				useSHANI = true
+				k := newHMACKey(key)
+				useSHANI = false
+				return k
+			})
+		})
+		t.Run("stdlib", func(t *testing.T) {
+			useSHANI = false
+			check(t, newHMACKey)
+			check(t, func(key []byte) hmacKey { return newHMACKey(key).owned() })
+		})
+	}
+}
+
+func BenchmarkHMACKey(b *testing.B) {
+	k := newHMACKey(encryptionKey)
+	var out [sha1Len]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		k.sumTo(&out, initVector, nil)
+	}
+}
+
+func BenchmarkHMACPair(b *testing.B) {
+	e, k := newHMACKey(encryptionKey), newHMACKey(integrityKey)
+	var pad, sign [sha1Len]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sumPair(&e, &pad, initVector, nil, &k, &sign, decryptedAdID, initVector)
+	}
+}
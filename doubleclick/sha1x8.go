@@ -0,0 +1,94 @@
+package doubleclick
+
+import "encoding/binary"
+
+// Number of lanes of multi-buffer SHA-1.
+const lanes = 8
+
+// Scratch of multi-buffer HMAC-SHA1.
+//
+// Computes HMACs of up to 8 one-block messages at once. Lanes keep their words interleaved (word-major order), so
+// each SIMD register holds the same word of all lanes.
+type hmacLanes struct {
+	// Interleaved message schedule and state.
+	w [16][lanes]uint32
+	h [5][lanes]uint32
+	// Computed HMACs.
+	out [lanes][sha1Len]byte
+}
+
+// Set message of lane i to concatenation of a and b.
+//
+// Length of a and b together must not exceed sha1MaxShortLen.
+func (l *hmacLanes) set(i int, a, b []byte) {
+	var blk [sha1BlockLen]byte
+	n := copy(blk[:], a)
+	n += copy(blk[n:], b)
+	blk[n] = 0x80
+	binary.BigEndian.PutUint64(blk[sha1BlockLen-8:], uint64(sha1BlockLen+n)<<3)
+	for j := 0; j < 16; j++ {
+		l.w[j][i] = binary.BigEndian.Uint32(blk[j*4:])
+	}
+}
+
+// Compute HMACs of messages of first n lanes using key k.
+//
+// Other lanes are computed as well but contain garbage. Messages must be set again before the next call.
+func (l *hmacLanes) sum(k *hmacKey, n int) {
+	// Inner hash.
+	for j := 0; j < 5; j++ {
+		for i := 0; i < lanes; i++ {
+			l.h[j][i] = k.ipad[j]
+		}
+	}
+	sha1Block8(&l.h, &l.w)
+
+	// Outer hash: inner digest is already interleaved, so it moves to the message words as is.
+	for j := 0; j < 5; j++ {
+		l.w[j] = l.h[j]
+		for i := 0; i < lanes; i++ {
+			l.h[j][i] = k.opad[j]
+		}
+	}
+	for j := 5; j < 16; j++ {
+		var v uint32
+		switch j {
+		case 5:
+			v = 0x80000000
+		case 15:
+			v = (sha1BlockLen + sha1Len) << 3
+		}
+		for i := 0; i < lanes; i++ {
+			l.w[j][i] = v
+		}
+	}
+	sha1Block8(&l.h, &l.w)
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < 5; j++ {
+			binary.BigEndian.PutUint32(l.out[i][j*4:], l.h[j][i])
+		}
+	}
+}
+
+// Pure Go multi-buffer SHA-1 compression function.
+//
+// Compresses lanes one by one, so results are identical to SIMD version.
+func sha1Block8Generic(h *[5][lanes]uint32, w *[16][lanes]uint32) {
+	for i := 0; i < lanes; i++ {
+		var (
+			hl [5]uint32
+			wl [16]uint32
+		)
+		for j := 0; j < 5; j++ {
+			hl[j] = h[j][i]
+		}
+		for j := 0; j < 16; j++ {
+			wl[j] = w[j][i]
+		}
+		sha1Words(&hl, &wl)
+		for j := 0; j < 5; j++ {
+			h[j][i] = hl[j]
+		}
+	}
+}
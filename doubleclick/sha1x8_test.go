@@ -0,0 +1,50 @@
+package doubleclick
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestHMACLanes(t *testing.T) {
+	check := func(t *testing.T) {
+		var l hmacLanes
+		k := newHMACKey(integrityKey)
+		msg := bytes.Repeat([]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd}, 8)
+		for n := 1; n <= lanes; n++ {
+			for i := 0; i < n; i++ {
+				l.set(i, msg[:i*5], initVector[:16-i])
+			}
+			l.sum(&k, n)
+			for i := 0; i < n; i++ {
+				h := hmac.New(sha1.New, integrityKey)
+				h.Write(msg[:i*5])
+				h.Write(initVector[:16-i])
+				if !bytes.Equal(l.out[i][:], h.Sum(nil)) {
+					t.Errorf("HMAC mismatch: lanes %d, lane %d", n, i)
+				}
+			}
+		}
+	}
+	t.Run("native", check)
+	if useAVX2 {
+		t.Run("generic", func(t *testing.T) {
+			useAVX2 = false
+			defer func() { useAVX2 = true }()
+			check(t)
+		})
+	}
+}
+
+func BenchmarkHMACLanes(b *testing.B) {
+	var l hmacLanes
+	k := newHMACKey(encryptionKey)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < lanes; j++ {
+			l.set(j, initVector, nil)
+		}
+		l.sum(&k, lanes)
+	}
+}
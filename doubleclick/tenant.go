@@ -0,0 +1,162 @@
+package doubleclick
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Tenants is a registry of key pairs of tenants (seats, billing or buyer IDs).
+//
+// Resolves tenant keys at encryption/decryption time, e.g. DecryptPriceFor(seatID, cipher, micros). Reads are
+// lock-free and all methods are thread-safe, so tenants may be added, replaced or removed while decryption is running.
+type Tenants struct {
+	// Policy is a decryption policy applied to all tenants.
+	// Must be set before first use of the registry.
+	Policy *Policy
+	// Encoding is a wire encoding of messages of all tenants.
+	// Must be set before first use of the registry.
+	Encoding Encoding
+	// Rounding is a rounding mode of float prices of all tenants.
+	// Must be set before first use of the registry.
+	Rounding Rounding
+
+	// Copy-on-write map of tenant codecs.
+	codecs atomic.Value
+	mux    sync.Mutex
+}
+
+// Set adds tenant with given ID or replaces keys of existing tenant.
+func (t *Tenants) Set(id string, key KeyPair) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	codecs := t.load()
+	cpy := make(map[string]*Codec, len(codecs)+1)
+	for k, v := range codecs {
+		cpy[k] = v
+	}
+	cpy[id] = t.newCodec(key)
+	t.codecs.Store(cpy)
+}
+
+// SetAll atomically replaces all tenants with given ones.
+//
+// Tenants missing in keys are removed.
+func (t *Tenants) SetAll(keys map[string]KeyPair) {
+	cpy := make(map[string]*Codec, len(keys))
+	for id, key := range keys {
+		cpy[id] = t.newCodec(key)
+	}
+	t.mux.Lock()
+	t.codecs.Store(cpy)
+	t.mux.Unlock()
+}
+
+// Remove removes tenant with given ID.
+//
+// Returns false if tenant doesn't exist.
+func (t *Tenants) Remove(id string) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	codecs := t.load()
+	if _, ok := codecs[id]; !ok {
+		return false
+	}
+	cpy := make(map[string]*Codec, len(codecs))
+	for k, v := range codecs {
+		if k != id {
+			cpy[k] = v
+		}
+	}
+	t.codecs.Store(cpy)
+	return true
+}
+
+// Has checks if tenant with given ID exists.
+func (t *Tenants) Has(id string) bool {
+	_, ok := t.load()[id]
+	return ok
+}
+
+// Len returns number of tenants.
+func (t *Tenants) Len() int {
+	return len(t.load())
+}
+
+// Codec returns codec of the tenant with given type.
+func (t *Tenants) Codec(id string, typ Type) (*Codec, error) {
+	c, err := t.codec(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.WithType(typ), nil
+}
+
+// EncryptFor encrypts plain of given type using keys of the tenant and appends message to dst.
+func (t *Tenants) EncryptFor(id string, typ Type, dst, initVec, plain []byte) ([]byte, error) {
+	c, err := t.codec(id)
+	if err != nil {
+		return dst, err
+	}
+	cpy := *c
+	cpy.typ = typ
+	return cpy.Encrypt(dst, initVec, plain)
+}
+
+// EncryptPriceFor encrypts price using keys of the tenant and appends message to dst.
+func (t *Tenants) EncryptPriceFor(id string, price float64, dst, initVec []byte, micros int) ([]byte, error) {
+	c, err := t.codec(id)
+	if err != nil {
+		return dst, err
+	}
+	return c.EncryptPrice(price, dst, initVec, micros)
+}
+
+// DecryptFor decrypts cipher of given type using keys of the tenant and appends payload to dst.
+func (t *Tenants) DecryptFor(id string, typ Type, dst, cipher []byte) ([]byte, error) {
+	c, err := t.codec(id)
+	if err != nil {
+		return dst, err
+	}
+	cpy := *c
+	cpy.typ = typ
+	return cpy.Decrypt(dst, cipher)
+}
+
+// DecryptPriceFor decrypts price using keys of the tenant.
+func (t *Tenants) DecryptPriceFor(id string, cipher []byte, micros int) (float64, error) {
+	c, err := t.codec(id)
+	if err != nil {
+		return 0, err
+	}
+	return c.DecryptPrice(cipher, micros)
+}
+
+// DecryptPriceMicrosFor decrypts price without division to micros using keys of the tenant.
+func (t *Tenants) DecryptPriceMicrosFor(id string, cipher []byte) (uint64, error) {
+	c, err := t.codec(id)
+	if err != nil {
+		return 0, err
+	}
+	return c.DecryptPriceMicros(cipher)
+}
+
+// Get codec of the tenant.
+func (t *Tenants) codec(id string) (*Codec, error) {
+	if c, ok := t.load()[id]; ok {
+		return c, nil
+	}
+	return nil, &TenantError{ID: id}
+}
+
+// Make codec of the tenant using registry options.
+func (t *Tenants) newCodec(key KeyPair) *Codec {
+	c := NewCodec(TypeRaw, key.EncryptionKey, key.IntegrityKey)
+	c.policy, c.enc, c.round = t.Policy, t.Encoding, t.Rounding
+	return c
+}
+
+// Load current map of codecs.
+func (t *Tenants) load() map[string]*Codec {
+	codecs, _ := t.codecs.Load().(map[string]*Codec)
+	return codecs
+}
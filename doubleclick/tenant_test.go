@@ -0,0 +1,119 @@
+package doubleclick
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestTenants(t *testing.T) {
+	t.Run("decrypt", func(t *testing.T) {
+		var r Tenants
+		r.Set("seat-1", KeyPair{EncryptionKey: encryptionKey, IntegrityKey: integrityKey})
+		r.Set("seat-2", KeyPair{EncryptionKey: encryptionKey1, IntegrityKey: integrityKey1})
+		price, err := r.DecryptPriceFor("seat-1", encryptedPrice, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price failed")
+		}
+		if _, err = r.DecryptPriceFor("seat-2", encryptedPrice, micros); err != ErrSignCheckFail {
+			t.Error("expected error", ErrSignCheckFail)
+		}
+		dst, err := r.DecryptFor("seat-1", TypeAdID, nil, encryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt AdID failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		var r Tenants
+		r.Set("seat-1", KeyPair{EncryptionKey: encryptionKey, IntegrityKey: integrityKey})
+		dst, err := r.EncryptPriceFor("seat-1", decryptedPrice, nil, initVector, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedPrice) {
+			t.Error("encrypt price failed")
+		}
+		if dst, err = r.EncryptFor("seat-1", TypeIDFA, dst[:0], initVector, decryptedIDFA); err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, encryptedIDFA) {
+			t.Error("encrypt IDFA failed")
+		}
+	})
+	t.Run("unknown", func(t *testing.T) {
+		var r Tenants
+		_, err := r.DecryptPriceFor("seat-3", encryptedPrice, micros)
+		if !errors.Is(err, ErrUnkTenant) {
+			t.Error("expected error", ErrUnkTenant)
+		}
+		if err == nil || err.Error() != `unknown tenant "seat-3"` {
+			t.Error("error must name tenant", err)
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		var r Tenants
+		r.Set("seat-1", KeyPair{EncryptionKey: encryptionKey1, IntegrityKey: integrityKey1})
+		r.Set("seat-2", KeyPair{EncryptionKey: encryptionKey1, IntegrityKey: integrityKey1})
+		r.Set("seat-1", KeyPair{EncryptionKey: encryptionKey, IntegrityKey: integrityKey})
+		if _, err := r.DecryptPriceFor("seat-1", encryptedPrice, micros); err != nil {
+			t.Error(err)
+		}
+		if !r.Remove("seat-2") || r.Remove("seat-2") || r.Has("seat-2") || r.Len() != 1 {
+			t.Error("remove tenant failed")
+		}
+		r.SetAll(map[string]KeyPair{"seat-3": {EncryptionKey: encryptionKey, IntegrityKey: integrityKey}})
+		if r.Has("seat-1") || !r.Has("seat-3") {
+			t.Error("replace tenants failed")
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var (
+			r  Tenants
+			wg sync.WaitGroup
+		)
+		key := KeyPair{EncryptionKey: encryptionKey, IntegrityKey: integrityKey}
+		r.Set("seat", key)
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if _, err := r.DecryptPriceFor("seat", encryptedPrice, micros); err != nil {
+						t.Error(err)
+					}
+				}
+			}()
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					id := strconv.Itoa(i*100 + j)
+					r.Set(id, key)
+					r.Remove(id)
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+}
+
+func BenchmarkTenants(b *testing.B) {
+	var r Tenants
+	for i := 0; i < 50; i++ {
+		r.Set("seat-"+strconv.Itoa(i), KeyPair{EncryptionKey: encryptionKey, IntegrityKey: integrityKey})
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.DecryptPriceFor("seat-7", encryptedPrice, micros); err != nil {
+			b.Error(err)
+		}
+	}
+}
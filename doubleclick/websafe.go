@@ -0,0 +1,76 @@
+package doubleclick
+
+import "encoding/binary"
+
+// Size of internal array of decoded web-safe messages. Fits messages of all built-in fixed length types.
+const wsArrayLen = 64
+
+// EncryptWebSafe encrypts plain and encodes result to web-safe base64 in one call.
+//
+// Raw message is kept in the internal buffer, so no allocations are performed after warm-up.
+func (d *DoubleClick) EncryptWebSafe(dst, initVec, plain []byte) ([]byte, error) {
+	enc := d.enc
+	d.enc = EncodingWebSafe
+	dst, err := d.EncryptFn(dst, initVec, plain, nil)
+	d.enc = enc
+	return dst, err
+}
+
+// EncryptPriceWebSafe encrypts price and encodes result to web-safe base64 in one call.
+func (d *DoubleClick) EncryptPriceWebSafe(price float64, dst, initVec []byte, micros int) ([]byte, error) {
+	enc := d.enc
+	d.enc = EncodingWebSafe
+	dst, err := d.EncryptPrice(price, dst, initVec, micros)
+	d.enc = enc
+	return dst, err
+}
+
+// DecryptWebSafe decodes web-safe base64 message and decrypts it to dst in one call.
+//
+// Length of the message is checked before decoding. Messages up to 64 bytes (all built-in fixed length types) are
+// decoded to the fixed-size internal array, so the call never allocates. Longer messages use the internal buffer,
+// which grows up to the longest message.
+func (d *DoubleClick) DecryptWebSafe(dst, msg []byte) ([]byte, error) {
+	ti, ok := lookupType(d.typ)
+	if !ok {
+		return dst, ErrUnkType
+	}
+	n := b64DecodedLen(msg)
+	if ti.payloadLen > 0 && n != ti.payloadLen+msgOverhead {
+		return dst, ErrBadMsgLen
+	}
+	if n > wsArrayLen {
+		var err error
+		if d.wbuf, err = b64DecodeAny(d.wbuf[:0], msg); err != nil {
+			return dst, err
+		}
+		return d.decryptFn(dst, d.wbuf, nil)
+	}
+	cipher, err := b64DecodeAny(d.warr[:0], msg)
+	if err != nil {
+		return dst, err
+	}
+	return d.decryptFn(dst, cipher, nil)
+}
+
+// DecryptPriceWebSafe decodes web-safe base64 message and decrypts price in one call.
+func (d *DoubleClick) DecryptPriceWebSafe(msg []byte, micros int) (float64, error) {
+	if micros <= 0 {
+		return 0, ErrBadMicros
+	}
+	// Increase buffer with +1 payload length and use extra space as a destination array.
+	bufLen := bufPadLen + payloadLenPrice + bufSignLen
+	doubleBufLen := bufLen + payloadLenPrice
+	if len(d.buf) < doubleBufLen {
+		d.buf = append(d.buf, make([]byte, doubleBufLen-len(d.buf))...)
+	}
+
+	decrypted, err := d.DecryptWebSafe(d.buf[bufLen:bufLen], msg)
+	if err != nil {
+		return 0, err
+	}
+	if len(decrypted) != payloadLenPrice {
+		return 0, ErrBadMsgLen
+	}
+	return float64(binary.BigEndian.Uint64(decrypted)) / float64(micros), nil
+}
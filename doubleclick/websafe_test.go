@@ -2,6 +2,7 @@ package doubleclick
 
 import (
 	"bytes"
+	"encoding/base64"
 	"testing"
 )
 
@@ -44,6 +45,169 @@ func TestWebSafe(t *testing.T) {
 	})
 }
 
+func TestWebSafeRFC(t *testing.T) {
+	d := New(TypePrice, encryptionKey, integrityKey)
+	src := make([]byte, 0, 64)
+	for i := 0; i < 64; i++ {
+		src = append(src, byte(i*37+0xf8))
+		expect := base64.RawURLEncoding.EncodeToString(src)
+		dst, err := d.WebSafeEncode(nil, src)
+		if err != nil {
+			t.Error(err)
+		}
+		if string(dst) != expect {
+			t.Errorf("web safe encode mismatch: expect %s, got %s", expect, dst)
+		}
+		if dst, err = d.WebSafeDecode(dst[:0], []byte(base64.URLEncoding.EncodeToString(src))); err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, src) {
+			t.Error("web safe decode padded failed")
+		}
+	}
+	if _, err := d.WebSafeDecode(nil, []byte("abcde")); err != ErrBadBase64 {
+		t.Error("expected error", ErrBadBase64)
+	}
+	if _, err := d.WebSafeDecode(nil, []byte("ab*d")); err != ErrBadBase64 {
+		t.Error("expected error", ErrBadBase64)
+	}
+}
+
+func TestWebSafePipeline(t *testing.T) {
+	wsAdID := []byte(base64.RawURLEncoding.EncodeToString(encryptedAdID))
+	wsPrice := []byte(base64.RawURLEncoding.EncodeToString(encryptedPrice))
+	t.Run("decrypt", func(t *testing.T) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		dst, err := d.DecryptWebSafe(nil, wsAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, decryptedAdID) {
+			t.Error("decrypt web safe failed")
+		}
+	})
+	t.Run("encrypt", func(t *testing.T) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		dst, err := d.EncryptWebSafe(nil, initVector, decryptedAdID)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, wsAdID) {
+			t.Error("encrypt web safe failed")
+		}
+		if d.enc != EncodingRaw {
+			t.Error("encoding must be restored")
+		}
+	})
+	t.Run("price", func(t *testing.T) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		price, err := d.DecryptPriceWebSafe(wsPrice, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if price != decryptedPrice {
+			t.Error("decrypt price web safe failed")
+		}
+		dst, err := d.EncryptPriceWebSafe(decryptedPrice, nil, initVector, micros)
+		if err != nil {
+			t.Error(err)
+		}
+		if !bytes.Equal(dst, wsPrice) {
+			t.Error("encrypt price web safe failed")
+		}
+	})
+	t.Run("fixed buffer", func(t *testing.T) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		if _, err := d.DecryptWebSafe(nil, wsAdID); err != nil {
+			t.Error(err)
+		}
+		if cap(d.wbuf) != 0 {
+			t.Error("fixed length message must be decoded to the internal array")
+		}
+		if _, err := d.DecryptWebSafe(nil, wsPrice); err != ErrBadMsgLen {
+			t.Error("expected error", ErrBadMsgLen)
+		}
+		raw := New(TypeRaw, encryptionKey, integrityKey)
+		msg, _ := raw.EncryptWebSafe(nil, initVector, bytes.Repeat(decryptedAdID, 5))
+		if dst, err := raw.DecryptWebSafe(nil, msg); err != nil || !bytes.Equal(dst, bytes.Repeat(decryptedAdID, 5)) {
+			t.Error("decrypt long web safe message failed:", err)
+		}
+	})
+	t.Run("reset", func(t *testing.T) {
+		d := New(TypeRaw, encryptionKey, integrityKey)
+		msg, _ := d.EncryptWebSafe(nil, initVector, bytes.Repeat(decryptedAdID, 5))
+		if _, err := d.DecryptWebSafe(nil, msg); err != nil {
+			t.Error(err)
+		}
+		if _, err := d.DecryptWebSafe(nil, wsAdID); err != nil {
+			t.Error(err)
+		}
+		d.pbuf = append(d.pbuf, decryptedAdID...)
+		wbuf, pbuf := d.wbuf[:cap(d.wbuf)], d.pbuf[:cap(d.pbuf)]
+		d.Reset()
+		if len(d.wbuf) != 0 || len(d.pbuf) != 0 {
+			t.Error("buffers must be truncated")
+		}
+		if bytes.Count(wbuf, []byte{0}) != len(wbuf) || bytes.Count(pbuf, []byte{0}) != len(pbuf) ||
+			d.warr != [wsArrayLen]byte{} {
+			t.Error("buffers must be wiped")
+		}
+	})
+}
+
+func BenchmarkWebSafePipeline(b *testing.B) {
+	wsAdID := []byte(base64.RawURLEncoding.EncodeToString(encryptedAdID))
+	wsPrice := []byte(base64.RawURLEncoding.EncodeToString(encryptedPrice))
+	b.Run("decrypt", func(b *testing.B) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if dst, err = d.DecryptWebSafe(dst[:0], wsAdID); err != nil {
+				b.Error(err)
+			}
+			if !bytes.Equal(dst, decryptedAdID) {
+				b.Error("decrypt web safe failed")
+			}
+		}
+	})
+	b.Run("encrypt", func(b *testing.B) {
+		d := New(TypeAdID, encryptionKey, integrityKey)
+		var (
+			dst []byte
+			err error
+		)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if dst, err = d.EncryptWebSafe(dst[:0], initVector, decryptedAdID); err != nil {
+				b.Error(err)
+			}
+			if !bytes.Equal(dst, wsAdID) {
+				b.Error("encrypt web safe failed")
+			}
+		}
+	})
+	b.Run("decrypt price", func(b *testing.B) {
+		d := New(TypePrice, encryptionKey, integrityKey)
+		b.ResetTimer()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			price, err := d.DecryptPriceWebSafe(wsPrice, micros)
+			if err != nil {
+				b.Error(err)
+			}
+			if price != decryptedPrice {
+				b.Error("decrypt price web safe failed")
+			}
+		}
+	})
+}
+
 func BenchmarkWebSafe(b *testing.B) {
 	b.Run("decrypt", func(b *testing.B) {
 		var (
@@ -0,0 +1,187 @@
+package doubleclick
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Parse minimal subset of YAML: nested mappings of scalar values.
+//
+// Supports comments, quoted scalars and indentation by spaces. Sequences, anchors, multi-line scalars and flow
+// collections aren't supported, since key files don't need them. Mapping values are either string or nested mapping.
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	type level struct {
+		indent int
+		m      map[string]interface{}
+	}
+	root := make(map[string]interface{})
+	stack := []level{{indent: -1, m: root}}
+	// Key waiting for nested mapping.
+	var (
+		pending    map[string]interface{}
+		pendingKey string
+	)
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		lineno := i + 1
+		s := strings.TrimRight(string(line), " \t\r")
+		if strings.HasPrefix(s, "---") && len(strings.TrimSpace(s)) == 3 {
+			continue
+		}
+		body := strings.TrimLeft(s, " ")
+		if len(body) == 0 || body[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(body, "\t") {
+			return nil, yamlError(lineno, "tabs are not allowed in indentation")
+		}
+		if strings.HasPrefix(body, "- ") || body == "-" {
+			return nil, yamlError(lineno, "sequences are not supported")
+		}
+		indent := len(s) - len(body)
+
+		// Open nested mapping of the pending key.
+		if pending != nil {
+			if indent > stack[len(stack)-1].indent {
+				m := make(map[string]interface{})
+				pending[pendingKey] = m
+				stack = append(stack, level{indent: indent, m: m})
+			} else {
+				// Key without value and nested mapping.
+				pending[pendingKey] = ""
+			}
+			pending = nil
+		}
+		// Close nested mappings.
+		for indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		if indent != stack[len(stack)-1].indent && len(stack) > 1 {
+			return nil, yamlError(lineno, "bad indentation")
+		}
+		if len(stack) == 1 && indent != 0 {
+			return nil, yamlError(lineno, "bad indentation")
+		}
+
+		key, value, err := yamlSplit(body)
+		if err != nil {
+			return nil, yamlError(lineno, "malformed mapping entry")
+		}
+		m := stack[len(stack)-1].m
+		if _, ok := m[key]; ok {
+			return nil, yamlError(lineno, "duplicate key "+strconv.Quote(key))
+		}
+		if len(value) == 0 {
+			pending, pendingKey = m, key
+			continue
+		}
+		if m[key], err = yamlScalar(value); err != nil {
+			return nil, yamlError(lineno, "malformed scalar value")
+		}
+	}
+	if pending != nil {
+		pending[pendingKey] = ""
+	}
+	return root, nil
+}
+
+// Split mapping line to key and raw value.
+func yamlSplit(s string) (key, value string, err error) {
+	if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+		// Quoted key.
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 {
+			return "", "", ErrBadYAML
+		}
+		key, s = s[1:end+1], s[end+2:]
+		if !strings.HasPrefix(s, ":") {
+			return "", "", ErrBadYAML
+		}
+		return key, strings.TrimSpace(s[1:]), nil
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", ErrBadYAML
+		}
+		i = len(s) - 1
+	}
+	key = strings.TrimSpace(s[:i])
+	if len(key) == 0 {
+		return "", "", ErrBadYAML
+	}
+	return key, strings.TrimSpace(s[i+1:]), nil
+}
+
+// Decode scalar value: quoted string or plain value with optional trailing comment.
+func yamlScalar(s string) (string, error) {
+	switch s[0] {
+	case '"':
+		end := 1
+		for ; end < len(s); end++ {
+			if s[end] == '\\' {
+				end++
+				continue
+			}
+			if s[end] == '"' {
+				break
+			}
+		}
+		if end >= len(s) || !yamlTail(s[end+1:]) {
+			return "", ErrBadYAML
+		}
+		return strconv.Unquote(s[:end+1])
+	case '\'':
+		var buf strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				buf.WriteByte(s[i])
+				continue
+			}
+			// Escaped quote.
+			if i+1 < len(s) && s[i+1] == '\'' {
+				buf.WriteByte('\'')
+				i++
+				continue
+			}
+			if !yamlTail(s[i+1:]) {
+				return "", ErrBadYAML
+			}
+			return buf.String(), nil
+		}
+		return "", ErrBadYAML
+	case '[', '{', '&', '*', '|', '>':
+		return "", ErrBadYAML
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// Check that rest of the line after quoted scalar is empty or a comment.
+func yamlTail(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) == 0 || s[0] == '#'
+}
+
+func yamlError(line int, msg string) error {
+	return &YAMLError{Line: line, Msg: msg}
+}
+
+// YAMLError describes malformed YAML key file.
+//
+// Matches ErrBadYAML using errors.Is.
+type YAMLError struct {
+	Line int
+	Msg  string
+}
+
+func (e *YAMLError) Error() string {
+	return ErrBadYAML.Error() + ": line " + strconv.Itoa(e.Line) + ": " + e.Msg
+}
+
+func (e *YAMLError) Is(target error) bool {
+	return target == ErrBadYAML
+}
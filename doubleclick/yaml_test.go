@@ -0,0 +1,65 @@
+package doubleclick
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc, err := parseYAML([]byte(`---
+# Keys of seats.
+seat-1:
+  encryption_key: "a\"b" # quoted
+  integrity_key: 'it''s'
+  empty:
+
+"seat 2":
+    key_id: v2 # comment
+top: value
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"seat-1": map[string]interface{}{
+			"encryption_key": `a"b`,
+			"integrity_key":  "it's",
+			"empty":          "",
+		},
+		"seat 2": map[string]interface{}{"key_id": "v2"},
+		"top":    "value",
+	}
+	if !reflect.DeepEqual(doc, expect) {
+		t.Errorf("parse YAML mismatch: %#v", doc)
+	}
+
+	stages := []struct {
+		src  string
+		line int
+	}{
+		{"a: 1\n  b: 2", 2},
+		{"a:\n  - 1", 2},
+		{"a: 1\na: 2", 2},
+		{"a:\n  b: 1\n   c: 2", 3},
+		{"a: \"unterminated", 1},
+		{"a: [1, 2]", 1},
+		{"just text", 1},
+		{"a:\n\tb: 1", 2},
+	}
+	for _, st := range stages {
+		_, err := parseYAML([]byte(st.src))
+		var yerr *YAMLError
+		if !errors.As(err, &yerr) || yerr.Line != st.line || !errors.Is(err, ErrBadYAML) {
+			t.Errorf("expected YAML error at line %d, got %v", st.line, err)
+		}
+	}
+}
+
+func BenchmarkParseYAML(b *testing.B) {
+	src := []byte("seat-1:\n  encryption_key: abc\n  integrity_key: def\nseat-2:\n  encryption_key: ghi\n  integrity_key: jkl\n")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = parseYAML(src)
+	}
+}